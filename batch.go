@@ -0,0 +1,436 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap"
+)
+
+const defaultConcurrency = 5
+
+// Centroid geocodes addrs and returns the spherical centroid of the resolved points,
+// unresolvable addresses are logged and skipped
+func (g *geoCodeService) Centroid(ctx context.Context, addrs []*AddressQuery) (*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	pts := g.geocodeUnique(ctx, addrs)
+
+	resolved := []*Point{}
+	for i, pt := range pts {
+		if pt == nil {
+			g.log(ctx).Info("skipping unresolved address", zap.Int("index", i))
+			continue
+		}
+		resolved = append(resolved, pt)
+	}
+	if len(resolved) < 1 {
+		g.log(ctx).Error(NO_RESULTS)
+		return nil, ErrGeoCodeNoResults
+	}
+
+	return centroid(resolved)
+}
+
+// BatchEstimate expected api usage of a batch geocode
+type BatchEstimate struct {
+	Addresses     int
+	UniqueQueries int
+	// Cost estimated in dollars using Config.PricePerCall
+	Cost float64
+}
+
+// EstimateBatchCost counts the distinct queries geocoding addrs would send and their cost,
+// without calling the api
+func (g *geoCodeService) EstimateBatchCost(addrs []*AddressQuery) BatchEstimate {
+	_, queries := g.uniqueQueries(addrs)
+	return BatchEstimate{
+		Addresses:     len(addrs),
+		UniqueQueries: len(queries),
+		Cost:          float64(len(queries)) * g.PricePerCall,
+	}
+}
+
+// uniqueQueries groups addrs by their normalized address string in the configured field order,
+// as the cache keys them, returning the indices of each group and its query
+func (g *geoCodeService) uniqueQueries(addrs []*AddressQuery) (map[string][]int, map[string]*AddressQuery) {
+	byKey := map[string][]int{}
+	queries := map[string]*AddressQuery{}
+	for i, a := range addrs {
+		if a == nil {
+			continue
+		}
+		q := *a
+		if q.Country == "" {
+			q.Country = "USA"
+		}
+		key := normalizeQuery(g.addressString(&q))
+		if _, ok := queries[key]; !ok {
+			queries[key] = &q
+		}
+		byKey[key] = append(byKey[key], i)
+	}
+	return byKey, queries
+}
+
+// geocodeUnique concurrently geocodes each distinct address once,
+// returning points parallel to addrs with nil for failed lookups
+func (g *geoCodeService) geocodeUnique(ctx context.Context, addrs []*AddressQuery) []*Point {
+	byKey, queries := g.uniqueQueries(addrs)
+
+	keys := make([]string, 0, len(queries))
+	for key := range queries {
+		keys = append(keys, key)
+	}
+
+	var mu sync.Mutex
+	pts := make([]*Point, len(addrs))
+	runLimited(ctx, len(keys), g.concurrency(), func(k int) {
+		pt, err := g.GeocodeAddress(ctx, queries[keys[k]])
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, i := range byKey[keys[k]] {
+			pts[i] = pt
+		}
+	})
+
+	return pts
+}
+
+// ClusterByLocality reverse geocodes points, concurrently and once per distinct coordinate, and groups them
+// by locality keyed with its state and country, e.g. "Springfield, IL, US", points that don't resolve
+// to a locality are logged and skipped
+func (g *geoCodeService) ClusterByLocality(ctx context.Context, points []*Point) (map[string][]*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	byKey := map[string][]*Point{}
+	keys := []string{}
+	for i, p := range points {
+		if p == nil || !p.IsValid() {
+			g.log(ctx).Info("skipping invalid point", zap.Int("index", i))
+			continue
+		}
+		key := p.Key(cacheKeyPrecision)
+		if _, ok := byKey[key]; !ok {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], p)
+	}
+
+	var mu sync.Mutex
+	clusters := map[string][]*Point{}
+	runLimited(ctx, len(keys), g.concurrency(), func(k int) {
+		pts := byKey[keys[k]]
+		locality, err := g.locality(ctx, pts[0])
+		if err != nil {
+			g.log(ctx).Info("skipping unresolved point", zap.String("point", keys[k]))
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		clusters[locality] = append(clusters[locality], pts...)
+	})
+
+	return clusters, nil
+}
+
+// locality reverse geocodes p and returns the first locality, or postal town, among the results keyed
+// by localityKey, the result naming it is cached like other reverse lookups
+func (g *geoCodeService) locality(ctx context.Context, p *Point) (string, error) {
+	key := "reverse|" + p.Key(cacheKeyPrecision)
+	if e, ok := g.cache.get(key); ok && e.result != nil {
+		if r, name, ok := localityResult([]Result{*e.result}); ok {
+			return localityKey(r, name), nil
+		}
+	}
+
+	req := &ReverseGeocodeRequest{
+		LatLng: LatLng{
+			Lat: p.Latitude,
+			Lng: p.Longitude,
+		},
+		Language: g.Language,
+	}
+	resp, err := g.backend().ReverseGeocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return "", apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("ClusterByLocality", req, resp)
+
+	r, name, ok := localityResult(usableResults(resp))
+	if !ok {
+		return "", ErrGeoCodeNoResults
+	}
+	g.cache.put(key, g.newPoint(r), false, &r)
+	return localityKey(r, name), nil
+}
+
+// localityKey qualifies a locality name with the result's state and country short names,
+// so same named localities in different regions aren't merged
+func localityKey(r Result, name string) string {
+	parts := []string{name}
+	for _, typ := range []string{"administrative_area_level_1", "country"} {
+		if v := componentShortName(r.AddressComponents, typ); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// localityResult returns the first result with a locality, or else postal town, and its name
+func localityResult(results []Result) (Result, string, bool) {
+	for _, typ := range []string{"locality", "postal_town"} {
+		for _, r := range results {
+			if name := componentName(r.AddressComponents, typ); name != "" {
+				return r, name, true
+			}
+		}
+	}
+	return Result{}, "", false
+}
+
+// AddressAudit field match details of a batch row
+type AddressAudit struct {
+	// Point geocoded point, nil when Err is set
+	Point *Point
+	// FieldMatch per requested field whether the result matched it, see GeocodeAddressWithFieldMatch
+	FieldMatch map[string]bool
+	// Mismatches sorted names of the requested fields the result didn't match
+	Mismatches []string
+	Err        error
+}
+
+// AuditBatch geocodes addrs concurrently and reports for each row, parallel to addrs,
+// which requested fields the result matched, rows that fail to geocode carry their error
+func (g *geoCodeService) AuditBatch(ctx context.Context, addrs []*AddressQuery) ([]AddressAudit, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	audits := make([]AddressAudit, len(addrs))
+	runLimited(ctx, len(addrs), g.concurrency(), func(i int) {
+		if addrs[i] == nil {
+			audits[i].Err = ErrInvalidAddress
+			return
+		}
+		q := *addrs[i]
+		pt, meta, err := g.GeocodeAddressWithFieldMatch(ctx, &q)
+		if err != nil {
+			audits[i].Err = err
+			return
+		}
+		audits[i].Point, audits[i].FieldMatch = pt, meta.FieldMatch
+		for field, ok := range meta.FieldMatch {
+			if !ok {
+				audits[i].Mismatches = append(audits[i].Mismatches, field)
+			}
+		}
+		sort.Strings(audits[i].Mismatches)
+	})
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return audits, nil
+}
+
+// BatchError aggregates the failures of a batch, Errors is parallel to the batch input with nil for successes
+type BatchError struct {
+	Errors []error
+}
+
+func (e *BatchError) Error() string {
+	failed := 0
+	var first error
+	for _, err := range e.Errors {
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			failed++
+		}
+	}
+	return fmt.Sprintf("%d of %d failed, first error: %v", failed, len(e.Errors), first)
+}
+
+// WarmCache geocodes addrs with up to concurrency lookups in flight, storing the results in the cache,
+// failed lookups are aggregated in a *BatchError
+func (g *geoCodeService) WarmCache(ctx context.Context, addrs []*AddressQuery, concurrency int) error {
+	return g.warmCache(ctx, addrs, concurrency, nil)
+}
+
+// WarmCacheAsync runs WarmCache in the background, returning a handle to monitor and cancel it
+func (g *geoCodeService) WarmCacheAsync(ctx context.Context, addrs []*AddressQuery, concurrency int) *BatchHandle {
+	h := &BatchHandle{
+		total:    len(addrs),
+		finished: make(chan struct{}),
+	}
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		h.cancel, h.err = func() {}, ErrNilContext
+		close(h.finished)
+		return h
+	}
+
+	ctx, h.cancel = context.WithCancel(ctx)
+	go func() {
+		defer close(h.finished)
+		defer h.cancel()
+		h.err = g.warmCache(ctx, addrs, concurrency, h.step)
+	}()
+	return h
+}
+
+// warmCache is WarmCache calling step, when set, as each address completes
+func (g *geoCodeService) warmCache(ctx context.Context, addrs []*AddressQuery, concurrency int, step func()) error {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return ErrNilContext
+	}
+	if g.cache == nil {
+		g.log(ctx).Error(ERR_CACHE_DISABLED)
+		return ErrCacheDisabled
+	}
+	if concurrency < 1 {
+		concurrency = g.concurrency()
+	}
+
+	_, errs := g.geocodeEach(ctx, addrs, concurrency, step)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	failed := false
+	for _, err := range errs {
+		if err != nil {
+			failed = true
+		}
+	}
+	if failed {
+		g.log(ctx).Error("error warming cache", zap.Int("addresses", len(addrs)))
+		return &BatchError{Errors: errs}
+	}
+	return nil
+}
+
+// GeocodeAddresses geocodes addrs with up to Config.Concurrency lookups in flight, returning points and
+// errors parallel to addrs, a failed lookup doesn't stop the batch. Once ctx is done no further lookups
+// are started and the remaining addresses fail with the context's error.
+func (g *geoCodeService) GeocodeAddresses(ctx context.Context, addrs []*AddressQuery) ([]*Point, []error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		errs := make([]error, len(addrs))
+		for i := range errs {
+			errs[i] = ErrNilContext
+		}
+		return make([]*Point, len(addrs)), errs
+	}
+	return g.geocodeEach(ctx, addrs, g.concurrency(), nil)
+}
+
+// geocodeEach geocodes addrs with up to concurrency lookups in flight, calling step, when set,
+// as each address completes, addresses not started once ctx is done fail with its error
+func (g *geoCodeService) geocodeEach(ctx context.Context, addrs []*AddressQuery, concurrency int, step func()) ([]*Point, []error) {
+	pts := make([]*Point, len(addrs))
+	errs := make([]error, len(addrs))
+	started := runLimited(ctx, len(addrs), concurrency, func(i int) {
+		if addrs[i] == nil {
+			errs[i] = ErrInvalidAddress
+		} else {
+			q := *addrs[i]
+			pts[i], errs[i] = g.GeocodeAddress(ctx, &q)
+		}
+		if step != nil {
+			step()
+		}
+	})
+	for j := started; j < len(addrs); j++ {
+		errs[j] = ctx.Err()
+	}
+
+	return pts, errs
+}
+
+// runLimited calls fn for each index below n, each on its own goroutine with up to concurrency in flight,
+// goroutines are only started as a slot frees up. Once ctx is done no more calls are started,
+// it waits for those in flight and returns how many were started.
+func runLimited(ctx context.Context, n, concurrency int, fn func(i int)) int {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	sem := make(chan struct{}, concurrency)
+	for i := 0; i < n; i++ {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+		}
+		if ctx.Err() != nil {
+			return i
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}(i)
+	}
+	return n
+}
+
+// concurrency lookups batch methods keep in flight, Config.Concurrency or defaultConcurrency
+func (g *geoCodeService) concurrency() int {
+	if g.Concurrency < 1 {
+		return defaultConcurrency
+	}
+	return g.Concurrency
+}
+
+// BatchHandle tracks a batch running in the background,
+// canceling the batch's context remains the primary way to stop it
+type BatchHandle struct {
+	cancel   context.CancelFunc
+	total    int
+	done     int64
+	finished chan struct{}
+	err      error
+}
+
+// Cancel stops the batch, lookups in flight are abandoned
+func (h *BatchHandle) Cancel() {
+	h.cancel()
+}
+
+// Progress returns the number of completed and total items
+func (h *BatchHandle) Progress() (done, total int) {
+	return int(atomic.LoadInt64(&h.done)), h.total
+}
+
+// Done is closed once the batch has finished
+func (h *BatchHandle) Done() <-chan struct{} {
+	return h.finished
+}
+
+// Wait blocks until the batch has finished and returns its error
+func (h *BatchHandle) Wait() error {
+	<-h.finished
+	return h.err
+}
+
+func (h *BatchHandle) step() {
+	atomic.AddInt64(&h.done, 1)
+}
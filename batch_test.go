@@ -0,0 +1,303 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestBatch(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"centroid of symmetric points, succeeds": testCentroid,
+		"cluster points by locality, succeeds":   testClusterByLocality,
+		"estimate batch cost, succeeds":          testEstimateBatchCost,
+		"cancel batch via handle, succeeds":      testBatchHandleCancel,
+		"audit batch field mismatches, succeeds": testAuditBatch,
+		"geocode addresses in order, succeeds":   testGeocodeAddresses,
+		"bounded batch goroutines, succeeds":     testBatchConcurrency,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testCentroid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	coords := map[string]maps.LatLng{
+		"north, USA": {Lat: 11, Lng: 20},
+		"south, USA": {Lat: 9, Lng: 20},
+		"east, USA":  {Lat: 10, Lng: 21},
+		"west, USA":  {Lat: 10, Lng: 19},
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			ll, ok := coords[r.Address]
+			if !ok {
+				return nil, nil
+			}
+			return []maps.GeocodingResult{fakeResult(ll.Lat, ll.Lng, r.Address)}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	pt, err := gsc.Centroid(ctx, []*AddressQuery{
+		{Street: "north"},
+		{Street: "south"},
+		{Street: "east"},
+		{Street: "west"},
+		{Street: "north"},
+		{Street: "south"},
+		{Street: "nowhere"},
+	})
+	require.NoError(t, err)
+	require.InDelta(t, 10.0, pt.Latitude, 0.01)
+	require.InDelta(t, 20.0, pt.Longitude, 0.01)
+	require.Equal(t, 5, c.geocodeCalls())
+
+	_, err = gsc.Centroid(ctx, []*AddressQuery{{Street: "nowhere"}})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+}
+
+func testClusterByLocality(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	city := func(lat float64) (string, string) {
+		switch {
+		case lat > 39.7:
+			return "Springfield", "IL"
+		case lat > 37.6:
+			return "San Francisco", "CA"
+		case lat > 37.3:
+			return "San Jose", "CA"
+		default:
+			return "Springfield", "MO"
+		}
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.LatLng.Lat == 0.5 {
+				return nil, nil
+			}
+			name, state := city(r.LatLng.Lat)
+			res := fakeResult(r.LatLng.Lat, r.LatLng.Lng, "")
+			res.AddressComponents = []maps.AddressComponent{
+				{LongName: name, ShortName: name, Types: []string{"locality", "political"}},
+				{LongName: state, ShortName: state, Types: []string{"administrative_area_level_1", "political"}},
+				{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+
+	sf1 := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	sf2 := &Point{Latitude: 37.8024, Longitude: -122.4058}
+	sj := &Point{Latitude: 37.3382, Longitude: -121.8863}
+	dup := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	ocean := &Point{Latitude: 0.5, Longitude: -150}
+
+	clusters, err := gsc.ClusterByLocality(ctx, []*Point{sf1, sj, nil, sf2, dup, ocean})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(clusters))
+	require.ElementsMatch(t, []*Point{sf1, sf2, dup}, clusters["San Francisco, CA, US"])
+	require.Equal(t, []*Point{sj}, clusters["San Jose, CA, US"])
+	require.Equal(t, 4, c.geocodeCalls())
+
+	// the repeated call is served from the cache
+	clusters, err = gsc.ClusterByLocality(ctx, []*Point{sf1, sj, sf2})
+	require.NoError(t, err)
+	require.Equal(t, []*Point{sj}, clusters["San Jose, CA, US"])
+	require.Equal(t, 4, c.geocodeCalls())
+
+	// same named localities in different states aren't merged
+	il := &Point{Latitude: 39.78, Longitude: -89.65}
+	mo := &Point{Latitude: 37.21, Longitude: -93.29}
+	clusters, err = gsc.ClusterByLocality(ctx, []*Point{il, mo})
+	require.NoError(t, err)
+	require.Equal(t, map[string][]*Point{
+		"Springfield, IL, US": {il},
+		"Springfield, MO, US": {mo},
+	}, clusters)
+}
+
+func testEstimateBatchCost(t *testing.T) {
+	c := &fakeMapsClient{}
+	addrs := []*AddressQuery{
+		{City: "Petaluma", State: "CA"},
+		{City: "Petaluma", State: "CA", Country: "USA"},
+		{City: "PETALUMA ", State: "ca"},
+		{City: "Sonoma", State: "CA"},
+		nil,
+		{PostalCode: "94952"},
+	}
+
+	est := newFakeService(t, Config{}, c).EstimateBatchCost(addrs)
+	require.Equal(t, 6, est.Addresses)
+	require.Equal(t, 3, est.UniqueQueries)
+	require.InDelta(t, 3*DEFAULT_PRICE_PER_CALL, est.Cost, 1e-9)
+
+	est = newFakeService(t, Config{PricePerCall: 0.004}, c).EstimateBatchCost(addrs)
+	require.InDelta(t, 0.012, est.Cost, 1e-9)
+	require.Equal(t, 0, c.geocodeCalls())
+}
+
+func testBatchHandleCancel(t *testing.T) {
+	release := make(chan struct{})
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			<-release
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, r.Address, "street_address")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 16}, c)
+
+	addrs := []*AddressQuery{}
+	for i := 0; i < 10; i++ {
+		addrs = append(addrs, &AddressQuery{Street: fmt.Sprintf("%d Main St", i+1), City: "Petaluma"})
+	}
+
+	h := gsc.WarmCacheAsync(context.Background(), addrs, 1)
+	release <- struct{}{}
+	release <- struct{}{}
+	require.Eventually(t, func() bool {
+		done, _ := h.Progress()
+		return done >= 2
+	}, time.Second, time.Millisecond)
+
+	h.Cancel()
+	close(release)
+	require.Equal(t, context.Canceled, h.Wait())
+
+	done, total := h.Progress()
+	require.Equal(t, 10, total)
+	require.Less(t, done, total)
+	<-h.Done()
+}
+
+func testAuditBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "locality")
+			res.AddressComponents = []maps.AddressComponent{
+				{LongName: "Petaluma", ShortName: "Petaluma", Types: []string{"locality", "political"}},
+				{LongName: "California", ShortName: "CA", Types: []string{"administrative_area_level_1", "political"}},
+				{LongName: "94952", ShortName: "94952", Types: []string{"postal_code"}},
+				{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	audits, err := gsc.AuditBatch(ctx, []*AddressQuery{
+		{City: "Petaluma", State: "CA", PostalCode: "94952"},
+		{City: "Petaluma", State: "NV", PostalCode: "94954"},
+		nil,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, len(audits))
+
+	require.NoError(t, audits[0].Err)
+	require.Empty(t, audits[0].Mismatches)
+	require.True(t, audits[0].FieldMatch["State"])
+
+	require.NoError(t, audits[1].Err)
+	require.Equal(t, []string{"PostalCode", "State"}, audits[1].Mismatches)
+	require.True(t, audits[1].FieldMatch["City"])
+
+	require.Equal(t, ErrInvalidAddress, audits[2].Err)
+}
+
+func testGeocodeAddresses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.Address == "Atlantis, USA" {
+				return nil, nil
+			}
+			// later addresses finish first
+			time.Sleep(time.Duration(len(r.Address)%5) * time.Millisecond)
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, r.Address, "locality")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{Concurrency: 3}, c)
+
+	addrs := []*AddressQuery{
+		{City: "Petaluma", State: "CA"},
+		{City: "Atlantis"},
+		nil,
+		{City: "Sonoma", State: "CA"},
+		{City: "Napa", State: "CA"},
+		{City: "Santa Rosa", State: "CA"},
+	}
+	pts, errs := gsc.GeocodeAddresses(ctx, addrs)
+	require.Equal(t, len(addrs), len(pts))
+	require.Equal(t, []error{nil, ErrGeoCodeNoResults, ErrInvalidAddress, nil, nil, nil}, errs)
+	require.Nil(t, pts[1])
+	require.Nil(t, pts[2])
+	for i, addr := range map[int]string{0: "Petaluma, CA, USA", 3: "Sonoma, CA, USA", 4: "Napa, CA, USA", 5: "Santa Rosa, CA, USA"} {
+		require.Equal(t, addr, pts[i].FormattedAddress)
+	}
+	require.Equal(t, 3, gsc.EffectiveConfig().Concurrency)
+
+	// the first lookup cancels the batch, the rest aren't started
+	c.geocodeFn = func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+		cancel()
+		return []maps.GeocodingResult{fakeResult(38.24, -122.64, r.Address, "locality")}, nil
+	}
+	calls := c.geocodeCalls()
+	gsc = newFakeService(t, Config{Concurrency: 1}, c)
+	pts, errs = gsc.GeocodeAddresses(ctx, addrs)
+	require.NoError(t, errs[0])
+	require.NotNil(t, pts[0])
+	for _, err := range errs[1:] {
+		require.Equal(t, context.Canceled, err)
+	}
+	require.Equal(t, calls+1, c.geocodeCalls())
+}
+
+func testBatchConcurrency(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var mu sync.Mutex
+	base, peak := runtime.NumGoroutine(), 0
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			mu.Lock()
+			if n := runtime.NumGoroutine(); n > peak {
+				peak = n
+			}
+			mu.Unlock()
+			time.Sleep(time.Millisecond)
+			return []maps.GeocodingResult{fakeResult(10, 20, r.Address, "locality")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{Concurrency: 2}, c)
+
+	addrs := make([]*AddressQuery, 50)
+	for i := range addrs {
+		addrs[i] = &AddressQuery{City: fmt.Sprintf("Town %d", i)}
+	}
+	_, err := gsc.Centroid(ctx, addrs)
+	require.NoError(t, err)
+	_, err = gsc.AuditBatch(ctx, addrs[:25])
+	require.NoError(t, err)
+
+	// goroutines are only started for free slots, not one per address
+	require.Equal(t, 75, c.geocodeCalls())
+	require.LessOrEqual(t, peak-base, 2)
+}
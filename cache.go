@@ -0,0 +1,245 @@
+package geocode
+
+import (
+	"container/list"
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// cacheKeyPrecision decimal places of coordinates in cache keys, about 10cm
+const cacheKeyPrecision = 6
+
+// cacheEntry cached geocoding outcome, result is nil for postal code fallbacks,
+// or directions response for route cache entries
+type cacheEntry struct {
+	key      string
+	pt       Point
+	degraded bool
+	result   *Result
+	routes   []*Route
+	storedAt time.Time
+}
+
+// pointCache size bounded LRU cache of geocoded points, or routes, with a ttl,
+// a nil cache is valid and never hits
+type pointCache struct {
+	mu      sync.Mutex
+	size    int
+	ttl     time.Duration
+	clock   Clock
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+func newPointCache(size int, ttl time.Duration, clock Clock) *pointCache {
+	if size <= 0 {
+		return nil
+	}
+	return &pointCache{
+		size:    size,
+		ttl:     ttl,
+		clock:   clock,
+		order:   list.New(),
+		entries: map[string]*list.Element{},
+	}
+}
+
+func (c *pointCache) get(key string) (*cacheEntry, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := el.Value.(*cacheEntry)
+	if c.ttl > 0 && c.clock.Now().Sub(e.storedAt) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	cp := *e
+	return &cp, true
+}
+
+func (c *pointCache) put(key string, pt *Point, degraded bool, result *Result) {
+	if c == nil || pt == nil {
+		return
+	}
+	e := &cacheEntry{key: key, pt: *pt, degraded: degraded, result: result}
+	e.pt.Types = append([]string(nil), pt.Types...)
+	c.store(e)
+}
+
+func (c *pointCache) putRoutes(key string, routes []*Route) {
+	if c == nil || len(routes) < 1 {
+		return
+	}
+	c.store(&cacheEntry{key: key, routes: routes})
+}
+
+func (c *pointCache) store(e *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e.storedAt = c.clock.Now()
+	if el, ok := c.entries[e.key]; ok {
+		el.Value = e
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[e.key] = c.order.PushFront(e)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// points returns copies of the unexpired cached points by key
+func (c *pointCache) points() map[string]*Point {
+	pts := map[string]*Point{}
+	if c == nil {
+		return pts
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.clock.Now()
+	for key, el := range c.entries {
+		e := el.Value.(*cacheEntry)
+		if c.ttl > 0 && now.Sub(e.storedAt) > c.ttl {
+			continue
+		}
+		pts[key] = e.point()
+	}
+	return pts
+}
+
+// normalizeQuery case and whitespace insensitive form of a query string for cache keys
+func normalizeQuery(q string) string {
+	q = strings.Join(strings.Fields(strings.ToLower(q)), " ")
+	return strings.ReplaceAll(q, " ,", ",")
+}
+
+// normalizePostalCode upper case postal code without whitespace for cache keys
+func normalizePostalCode(code string) string {
+	return strings.ToUpper(strings.Join(strings.Fields(code), ""))
+}
+
+// isStale reports whether the cache entry is older than StaleAfter
+func (g *geoCodeService) isStale(e *cacheEntry) bool {
+	return g.StaleAfter > 0 && g.Clock.Now().Sub(e.storedAt) > g.StaleAfter
+}
+
+// DumpCache returns a snapshot of the geocoding cache, copies of the cached points by cache key,
+// empty when caching is disabled
+func (g *geoCodeService) DumpCache() map[string]*Point {
+	return g.cache.points()
+}
+
+// point returns a copy of the cached point
+func (e *cacheEntry) point() *Point {
+	pt := e.pt
+	pt.Types = append([]string(nil), e.pt.Types...)
+	return &pt
+}
+
+// apiError passes ErrOffline through, mapping any other api error to err
+func apiError(apiErr, err error) error {
+	if apiErr == ErrOffline {
+		return ErrOffline
+	}
+	return err
+}
+
+// offlineProvider stands in for the provider in offline mode
+type offlineProvider struct{}
+
+func (offlineProvider) Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error) {
+	return nil, ErrOffline
+}
+
+func (offlineProvider) ReverseGeocode(ctx context.Context, r *ReverseGeocodeRequest) ([]Result, error) {
+	return nil, ErrOffline
+}
+
+func (offlineProvider) Directions(ctx context.Context, r *DirectionsRequest) ([]*Route, error) {
+	return nil, ErrOffline
+}
+
+func (offlineProvider) DistanceMatrix(ctx context.Context, r *DistanceMatrixRequest) (*RouteMatrix, error) {
+	return nil, ErrOffline
+}
+
+// offlineClient stands in for the maps client in offline mode
+type offlineClient struct{}
+
+func (offlineClient) Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+	return nil, ErrOffline
+}
+
+func (offlineClient) Directions(ctx context.Context, r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+	return nil, nil, ErrOffline
+}
+
+func (offlineClient) DistanceMatrix(ctx context.Context, r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+	return nil, ErrOffline
+}
+
+func (offlineClient) Timezone(ctx context.Context, r *maps.TimezoneRequest) (*maps.TimezoneResult, error) {
+	return nil, ErrOffline
+}
+
+func (offlineClient) Elevation(ctx context.Context, r *maps.ElevationRequest) ([]maps.ElevationResult, error) {
+	return nil, ErrOffline
+}
+
+func (offlineClient) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	return maps.FindPlaceFromTextResponse{}, ErrOffline
+}
+
+func (offlineClient) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	return maps.PlaceDetailsResult{}, ErrOffline
+}
+
+// failedClient stands in for a subclient that couldn't be constructed
+type failedClient struct {
+	err error
+}
+
+func (f failedClient) Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+	return nil, f.err
+}
+
+func (f failedClient) Directions(ctx context.Context, r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+	return nil, nil, f.err
+}
+
+func (f failedClient) DistanceMatrix(ctx context.Context, r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+	return nil, f.err
+}
+
+func (f failedClient) Timezone(ctx context.Context, r *maps.TimezoneRequest) (*maps.TimezoneResult, error) {
+	return nil, f.err
+}
+
+func (f failedClient) Elevation(ctx context.Context, r *maps.ElevationRequest) ([]maps.ElevationResult, error) {
+	return nil, f.err
+}
+
+func (f failedClient) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	return maps.FindPlaceFromTextResponse{}, f.err
+}
+
+func (f failedClient) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	return maps.PlaceDetailsResult{}, f.err
+}
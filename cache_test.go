@@ -0,0 +1,329 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestCache(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"offline mode serves cache only, succeeds": testOfflineOnly,
+		"warm cache, succeeds":                     testWarmCache,
+		"cache ttl expiry, succeeds":               testCacheTTL,
+		"stale cached results, succeeds":           testStaleAfter,
+		"normalized cache keys, succeeds":          testCacheNormalizedKeys,
+		"cached routes, succeeds":                  testRouteCache,
+		"dump cache snapshot, succeeds":            testDumpCache,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testCacheNormalizedKeys(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(37.42, -122.08, "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+	require.Equal(t, DEFAULT_CACHE_TTL, gsc.EffectiveConfig().CacheTTL)
+
+	first, err := gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"})
+	require.NoError(t, err)
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600  AMPHITHEATRE pkwy ", City: "mountain view", State: "ca"})
+	require.NoError(t, err)
+	require.Equal(t, first, pt)
+	require.Equal(t, 1, c.geocodeCalls())
+
+	_, err = gsc.Geocode(ctx, "sw1a 1aa", "GB")
+	require.NoError(t, err)
+	_, err = gsc.Geocode(ctx, "SW1A1AA", "gb")
+	require.NoError(t, err)
+	require.Equal(t, 2, c.geocodeCalls())
+
+	gsc = newFakeService(t, Config{}, c)
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"})
+	require.NoError(t, err)
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, 4, c.geocodeCalls())
+}
+
+func testRouteCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{{
+					StartAddress: "origin",
+					EndAddress:   "destination",
+					Duration:     10 * time.Minute,
+					Distance:     maps.Distance{Meters: 12000},
+				}},
+			}}, nil, nil
+		},
+	}
+	clock := newFakeClock()
+	gsc := newFakeService(t, Config{CacheSize: 10, Clock: clock}, c)
+	require.Equal(t, DEFAULT_ROUTE_CACHE_TTL, gsc.EffectiveConfig().RouteCacheTTL)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+	first, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	legs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, first, legs)
+	require.Equal(t, 1, len(c.routeReqs))
+
+	_, err = gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{Language: "es"})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(c.routeReqs))
+
+	clock.Advance(DEFAULT_ROUTE_CACHE_TTL + time.Minute)
+	_, err = gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(c.routeReqs))
+
+	// traffic aware routes bypass the cached route for the same endpoints
+	for i := 0; i < 2; i++ {
+		_, err = gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{DepartureTime: "now"})
+		require.NoError(t, err)
+	}
+	require.Equal(t, 5, len(c.routeReqs))
+	require.Equal(t, "now", c.routeReqs[4].DepartureTime)
+	_, err = gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{ArrivalTime: "1700000000"})
+	require.NoError(t, err)
+	require.Equal(t, 6, len(c.routeReqs))
+
+	_, err = gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, 6, len(c.routeReqs))
+}
+
+func testOfflineOnly(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.LatLng != nil {
+				return []maps.GeocodingResult{fakeResult(r.LatLng.Lat, r.LatLng.Lng, "Mountain View, CA, USA")}, nil
+			}
+			return []maps.GeocodingResult{fakeResult(37.42, -122.08, "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+
+	addr := &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"}
+	warm, err := gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	_, err = gsc.Geocode(ctx, "94043", "USA")
+	require.NoError(t, err)
+	_, err = gsc.GeocodeLatLong(ctx, 37.42, -122.08, "")
+	require.NoError(t, err)
+	require.Equal(t, 3, c.geocodeCalls())
+
+	gsc.OfflineOnly = true
+
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, warm, pt)
+	_, err = gsc.Geocode(ctx, "94043", "USA")
+	require.NoError(t, err)
+	_, err = gsc.GeocodeLatLong(ctx, 37.42, -122.08, "")
+	require.NoError(t, err)
+
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Sunnyvale", State: "CA"})
+	require.Equal(t, ErrOffline, err)
+	_, err = gsc.Geocode(ctx, "94040", "USA")
+	require.Equal(t, ErrOffline, err)
+	_, err = gsc.GetRouteForLatLong(ctx, pt, &Point{Latitude: 37.39, Longitude: -122.03})
+	require.Equal(t, ErrOffline, err)
+	require.Equal(t, 3, c.geocodeCalls())
+	require.Equal(t, 0, len(c.routeReqs))
+}
+
+func testWarmCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.Address == "Atlantis, USA" {
+				return nil, nil
+			}
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, r.Address)}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+
+	addrs := []*AddressQuery{
+		{City: "Petaluma", State: "CA"},
+		{City: "Sonoma", State: "CA"},
+		{City: "Napa", State: "CA"},
+		{City: "Atlantis"},
+	}
+	err := gsc.WarmCache(ctx, addrs, 2)
+	var berr *BatchError
+	require.True(t, errors.As(err, &berr))
+	require.Equal(t, []error{nil, nil, nil, ErrGeoCodeNoResults}, berr.Errors)
+	require.Equal(t, 4, c.geocodeCalls())
+	require.Equal(t, "", addrs[0].Country)
+
+	for _, a := range addrs[:3] {
+		pt, err := gsc.GeocodeAddress(ctx, a)
+		require.NoError(t, err)
+		require.Equal(t, a.addressString()+", USA", pt.FormattedAddress)
+	}
+	require.Equal(t, 4, c.geocodeCalls())
+
+	cancel()
+	err = gsc.WarmCache(ctx, []*AddressQuery{{City: "Cotati", State: "CA"}}, 1)
+	require.Equal(t, context.Canceled, err)
+
+	err = newFakeService(t, Config{}, c).WarmCache(context.Background(), addrs, 1)
+	require.Equal(t, ErrCacheDisabled, err)
+}
+
+// fakeClock Clock advanced manually
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- c.Advance(d)
+	return ch
+}
+
+func (c *fakeClock) Advance(d time.Duration) time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+func testCacheTTL(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")}, nil
+		},
+	}
+	clock := newFakeClock()
+	gsc := newFakeService(t, Config{CacheSize: 10, CacheTTL: OneHour, Clock: clock}, c)
+
+	_, err := gsc.Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+
+	clock.Advance(ThirtyMinutes)
+	_, err = gsc.Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+	require.Equal(t, 1, c.geocodeCalls())
+
+	clock.Advance(ThirtyMinutes + time.Second)
+	_, err = gsc.Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, c.geocodeCalls())
+}
+
+func testStaleAfter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Petaluma, CA, USA", "locality")}, nil
+		},
+	}
+	clock := newFakeClock()
+	gsc := newFakeService(t, Config{CacheSize: 10, CacheTTL: OneDay, StaleAfter: OneHour, Clock: clock}, c)
+
+	addr := &AddressQuery{City: "Petaluma", State: "CA"}
+	_, meta, err := gsc.GeocodeAddressWithMeta(ctx, addr)
+	require.NoError(t, err)
+	require.False(t, meta.Stale)
+
+	clock.Advance(OneHour)
+	_, meta, err = gsc.GeocodeAddressWithMeta(ctx, addr)
+	require.NoError(t, err)
+	require.False(t, meta.Stale)
+
+	clock.Advance(time.Second)
+	_, meta, err = gsc.GeocodeAddressWithMeta(ctx, addr)
+	require.NoError(t, err)
+	require.True(t, meta.Stale)
+	require.Equal(t, 1, c.geocodeCalls())
+
+	clock.Advance(OneDay)
+	_, meta, err = gsc.GeocodeAddressWithMeta(ctx, addr)
+	require.NoError(t, err)
+	require.False(t, meta.Stale)
+	require.Equal(t, 2, c.geocodeCalls())
+}
+
+func testDumpCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.Address == "Atlantis, USA" {
+				return nil, nil
+			}
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "locality")}, nil
+		},
+	}
+	require.Empty(t, newFakeService(t, Config{}, c).DumpCache())
+
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Atlantis"})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	dump := gsc.DumpCache()
+	require.Equal(t, 1, len(dump))
+	for _, cached := range dump {
+		require.Equal(t, pt, cached)
+		cached.Latitude = 0
+		cached.Types[0] = "country"
+	}
+	for k := range dump {
+		delete(dump, k)
+	}
+
+	dump = gsc.DumpCache()
+	require.Equal(t, 1, len(dump))
+	for _, cached := range dump {
+		require.Equal(t, pt, cached)
+	}
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, 2, c.geocodeCalls())
+}
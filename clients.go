@@ -0,0 +1,66 @@
+package geocode
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+// apiKind google maps api family a subclient serves, geocoding and routing go through the Provider instead
+type apiKind string
+
+const (
+	placesAPI    apiKind = "places"
+	elevationAPI apiKind = "elevation"
+	timezoneAPI  apiKind = "timezone"
+)
+
+var apiKinds = []apiKind{placesAPI, elevationAPI, timezoneAPI}
+
+// clientFactory constructs the subclient for an api
+type clientFactory func(api apiKind) (mapsClient, error)
+
+// lazyClient subclient constructed on first use
+type lazyClient struct {
+	once sync.Once
+	c    mapsClient
+}
+
+func newLazyClients() map[apiKind]*lazyClient {
+	clients := map[apiKind]*lazyClient{}
+	for _, k := range apiKinds {
+		clients[k] = &lazyClient{}
+	}
+	return clients
+}
+
+// backend returns the provider geocoding and routing calls go to,
+// or one failing every call with ErrOffline when OfflineOnly is set
+func (g *geoCodeService) backend() Provider {
+	if g.OfflineOnly {
+		return offlineProvider{}
+	}
+	return g.provider
+}
+
+// api returns the subclient for kind, constructing it on first use and wrapping it to retry
+// failed idempotent calls with MaxRetries, or one failing every call with ErrOffline when OfflineOnly is set
+func (g *geoCodeService) api(ctx context.Context, kind apiKind) mapsClient {
+	if g.OfflineOnly {
+		return offlineClient{}
+	}
+
+	lc := g.clients[kind]
+	lc.once.Do(func() {
+		c, err := g.newClient(kind)
+		if err != nil {
+			g.log(ctx).Error("error initializing google maps client", zap.String("api", string(kind)), zap.Error(err))
+			c = failedClient{err: err}
+		} else if g.MaxRetries > 0 {
+			c = retryClient{c: c, policy: retryPolicy{retries: g.MaxRetries, backoff: g.RetryBackoff, clock: g.Clock}}
+		}
+		lc.c = c
+	})
+	return lc.c
+}
@@ -0,0 +1,63 @@
+package geocode
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestLazyClients(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "route")
+			res.AddressComponents = []maps.AddressComponent{{LongName: "Kentucky St", Types: []string{"route"}}}
+			return []maps.GeocodingResult{res}, nil
+		},
+		findPlaceFn: func(r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+			return maps.FindPlaceFromTextResponse{Candidates: []maps.PlacesSearchResult{
+				{PlaceID: "cafe", Name: "Acme Coffee", FormattedAddress: "1 Main St, Petaluma, CA"},
+			}}, nil
+		},
+	}
+	var mu sync.Mutex
+	constructed := map[apiKind]int{}
+	gsc, err := newGeoCodeServiceWithFactory(Config{
+		AppLogger: logger.NewTestAppLogger(t.TempDir()),
+	}, googleProvider{c: c}, func(api apiKind) (mapsClient, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		constructed[api]++
+		return c, nil
+	})
+	require.NoError(t, err)
+
+	// geocoding and road names go through the provider, no subclient is built
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	_, err = gsc.NearestRoadName(ctx, &Point{Latitude: 38.24, Longitude: -122.64})
+	require.NoError(t, err)
+	require.Equal(t, map[apiKind]int{}, constructed)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = gsc.FindPlace(ctx, "Acme Coffee Petaluma")
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, map[apiKind]int{placesAPI: 1}, constructed)
+}
@@ -0,0 +1,21 @@
+package geocode
+
+import "time"
+
+// Clock source of time for the time dependent code, e.g. cache expiry,
+// replaceable for deterministic tests
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// realClock Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
@@ -0,0 +1,132 @@
+package geocode
+
+import (
+	"fmt"
+	"time"
+)
+
+// ConfigSummary resolved service configuration, with defaults applied and secrets masked
+type ConfigSummary struct {
+	GeocoderKey                string         `json:"geocoder_key"`
+	AuthMethod                 string         `json:"auth_method"`
+	Provider                   string         `json:"provider"`
+	FallbackProvider           string         `json:"fallback_provider"`
+	FallbackAfter              time.Duration  `json:"fallback_after"`
+	UserAgent                  string         `json:"user_agent"`
+	PreferTypes                []string       `json:"prefer_types"`
+	FallbackToPostalCode       bool           `json:"fallback_to_postal_code"`
+	SplitStreetUnit            bool           `json:"split_street_unit"`
+	PreferPostalCodeName       bool           `json:"prefer_postal_code_name"`
+	DistanceCalculator         string         `json:"distance_calculator"`
+	SphereRadiusMeters         float64        `json:"sphere_radius_meters"`
+	AuditHook                  bool           `json:"audit_hook"`
+	SelectResult               bool           `json:"select_result"`
+	StrictPostalValidation     bool           `json:"strict_postal_validation"`
+	TieBreaker                 TieBreaker     `json:"tie_breaker"`
+	RejectPartialMatches       bool           `json:"reject_partial_matches"`
+	UseViewportCenter          bool           `json:"use_viewport_center"`
+	CacheEnabled               bool           `json:"cache_enabled"`
+	CacheSize                  int            `json:"cache_size"`
+	CacheTTL                   time.Duration  `json:"cache_ttl"`
+	RouteCacheTTL              time.Duration  `json:"route_cache_ttl"`
+	StaleAfter                 time.Duration  `json:"stale_after"`
+	OfflineOnly                bool           `json:"offline_only"`
+	PricePerCall               float64        `json:"price_per_call"`
+	MaxAddressLength           int            `json:"max_address_length"`
+	AllowSameOriginDestination bool           `json:"allow_same_origin_destination"`
+	AddressFieldOrder          []AddressField `json:"address_field_order"`
+	MaxRetries                 int            `json:"max_retries"`
+	RetryBackoff               time.Duration  `json:"retry_backoff"`
+	TitleCaseAddresses         bool           `json:"title_case_addresses"`
+	DedupePlaceIDs             bool           `json:"dedupe_place_ids"`
+	Language                   string         `json:"language"`
+	Region                     string         `json:"region"`
+	Units                      Units          `json:"units"`
+	Concurrency                int            `json:"concurrency"`
+	CaptureRawResponses        bool           `json:"capture_raw_responses"`
+}
+
+// EffectiveConfig returns a summary of the configuration in effect, safe to log
+func (g *geoCodeService) EffectiveConfig() ConfigSummary {
+	ua := g.UserAgent
+	if ua == "" {
+		ua = DEFAULT_USER_AGENT
+	}
+
+	calc := fmt.Sprintf("%T", g.DistanceCalculator)
+	switch g.DistanceCalculator.(type) {
+	case vincentyCalculator:
+		calc = "vincenty"
+	case haversineCalculator:
+		calc = "haversine"
+	}
+
+	provider := "google"
+	if g.Provider != nil {
+		provider = fmt.Sprintf("%T", g.Provider)
+	}
+	fallback, fallbackAfter := "", time.Duration(0)
+	if g.FallbackProvider != nil {
+		fallback, fallbackAfter = fmt.Sprintf("%T", g.FallbackProvider), g.FallbackAfter
+		if fallbackAfter <= 0 {
+			fallbackAfter = DEFAULT_FALLBACK_AFTER
+		}
+	}
+
+	return ConfigSummary{
+		GeocoderKey:                maskSecret(g.GeocoderKey),
+		AuthMethod:                 "api_key",
+		Provider:                   provider,
+		FallbackProvider:           fallback,
+		FallbackAfter:              fallbackAfter,
+		UserAgent:                  ua,
+		PreferTypes:                append([]string{}, g.PreferTypes...),
+		FallbackToPostalCode:       g.FallbackToPostalCode,
+		SplitStreetUnit:            g.SplitStreetUnit,
+		PreferPostalCodeName:       g.PreferPostalCodeName,
+		DistanceCalculator:         calc,
+		SphereRadiusMeters:         g.SphereRadiusMeters,
+		AuditHook:                  g.OnResult != nil,
+		SelectResult:               g.SelectResult != nil,
+		StrictPostalValidation:     g.StrictPostalValidation,
+		TieBreaker:                 g.TieBreaker,
+		RejectPartialMatches:       g.RejectPartialMatches,
+		UseViewportCenter:          g.UseViewportCenter,
+		CacheEnabled:               g.cache != nil,
+		CacheSize:                  g.CacheSize,
+		CacheTTL:                   g.CacheTTL,
+		RouteCacheTTL:              g.RouteCacheTTL,
+		StaleAfter:                 g.StaleAfter,
+		OfflineOnly:                g.OfflineOnly,
+		PricePerCall:               g.PricePerCall,
+		MaxAddressLength:           g.MaxAddressLength,
+		AllowSameOriginDestination: g.AllowSameOriginDestination,
+		AddressFieldOrder:          g.addressFieldOrder(),
+		MaxRetries:                 g.MaxRetries,
+		RetryBackoff:               g.RetryBackoff,
+		TitleCaseAddresses:         g.TitleCaseAddresses,
+		DedupePlaceIDs:             g.DedupePlaceIDs,
+		Language:                   g.Language,
+		Region:                     g.Region,
+		Units:                      g.Units,
+		Concurrency:                g.concurrency(),
+		CaptureRawResponses:        g.raw != nil,
+	}
+}
+
+// addressFieldOrder the full field order address strings are formatted in
+func (g *geoCodeService) addressFieldOrder() []AddressField {
+	return resolveFieldOrder(g.AddressFieldOrder)
+}
+
+// maskSecret keeps only the last 4 characters of longer secrets behind a fixed width mask,
+// so the secret's length isn't revealed
+func maskSecret(s string) string {
+	if s == "" {
+		return ""
+	}
+	if len(s) <= 8 {
+		return "****"
+	}
+	return "****" + s[len(s)-4:]
+}
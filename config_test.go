@@ -0,0 +1,62 @@
+package geocode
+
+import (
+	"testing"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveConfig(t *testing.T) {
+	gsc := newFakeService(t, Config{GeocoderKey: "AIzaSyD-secret-key-1234"}, &fakeMapsClient{})
+
+	s := gsc.EffectiveConfig()
+	require.Equal(t, "****1234", s.GeocoderKey)
+	require.Equal(t, "****1234", newFakeService(t, Config{GeocoderKey: "a-much-longer-secret-key-1234"}, &fakeMapsClient{}).EffectiveConfig().GeocoderKey)
+	require.NotContains(t, s.GeocoderKey, "secret")
+	require.Equal(t, "api_key", s.AuthMethod)
+	require.Equal(t, DEFAULT_USER_AGENT, s.UserAgent)
+	require.Equal(t, "vincenty", s.DistanceCalculator)
+	require.Equal(t, DEFAULT_MAX_ADDRESS_LENGTH, s.MaxAddressLength)
+	require.Equal(t, DEFAULT_ADDRESS_FIELD_ORDER, s.AddressFieldOrder)
+	require.Equal(t, DEFAULT_PRICE_PER_CALL, s.PricePerCall)
+	require.False(t, s.CacheEnabled)
+
+	gsc = newFakeService(t, Config{
+		GeocoderKey:        "short",
+		SphereRadiusMeters: 1737400,
+		CacheSize:          100,
+		CacheTTL:           OneHour,
+	}, &fakeMapsClient{})
+	s = gsc.EffectiveConfig()
+	require.Equal(t, "****", s.GeocoderKey)
+	require.Equal(t, "haversine", s.DistanceCalculator)
+	require.True(t, s.CacheEnabled)
+	require.Equal(t, OneHour, s.CacheTTL)
+
+	// the partial order is reported as resolved for formatting
+	gsc = newFakeService(t, Config{
+		AddressFieldOrder: []AddressField{FIELD_POSTAL_CODE, FIELD_STATE, FIELD_POSTAL_CODE},
+	}, &fakeMapsClient{})
+	require.Equal(t, []AddressField{
+		FIELD_POSTAL_CODE, FIELD_STATE, FIELD_PREMISE, FIELD_STREET, FIELD_CITY, FIELD_COUNTRY,
+	}, gsc.EffectiveConfig().AddressFieldOrder)
+}
+
+func TestConfigValidation(t *testing.T) {
+	for name, tc := range map[string]struct {
+		cfg Config
+		err error
+	}{
+		"unknown address field, fails": {Config{AddressFieldOrder: []AddressField{FIELD_CITY, "BOGUS"}}, ErrInvalidAddressField},
+		"unknown tie breaker, fails":    {Config{TieBreaker: "SOUTHEAST"}, ErrInvalidTieBreaker},
+		"known enums, succeeds":         {Config{AddressFieldOrder: []AddressField{FIELD_CITY}, TieBreaker: TIE_BREAK_PLACE_ID}, nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tc.cfg.Provider = &fakeProvider{}
+			tc.cfg.AppLogger = logger.NewTestAppLogger(t.TempDir())
+			_, err := NewGeoCodeService(tc.cfg)
+			require.Equal(t, tc.err, err)
+		})
+	}
+}
@@ -15,22 +15,133 @@ const (
 	ThirtyMinutes = 30 * time.Minute
 )
 
+const DEFAULT_USER_AGENT = "comfforts-geocode"
+
+// DEFAULT_CACHE_TTL expiry of cached points, the maps platform terms allow caching for up to 30 days
+const DEFAULT_CACHE_TTL = ThirtyDays
+
+// DEFAULT_ROUTE_CACHE_TTL expiry of cached routes, shorter than points' as road conditions change
+const DEFAULT_ROUTE_CACHE_TTL = OneDay
+
+// EARTH_RADIUS_METERS mean earth radius
+const EARTH_RADIUS_METERS = 6371008.8
+
+// DEFAULT_SIMPLE_TIMEOUT bounds the context free convenience helpers
+const DEFAULT_SIMPLE_TIMEOUT = 30 * time.Second
+
+// DEFAULT_FALLBACK_AFTER time the primary provider has to answer before a fallback provider is raced
+const DEFAULT_FALLBACK_AFTER = 2 * time.Second
+
+// DEFAULT_RETRY_BACKOFF wait before the first retry of a failed api call
+const DEFAULT_RETRY_BACKOFF = 200 * time.Millisecond
+
+// MAX_RETRY_BACKOFF longest wait between retries
+const MAX_RETRY_BACKOFF = 10 * time.Second
+
+// SAME_POINT_TOLERANCE_METERS distance under which route endpoints are considered the same point
+const SAME_POINT_TOLERANCE_METERS = 1.0
+
+// MAX_GRID_POINTS largest grid GridPoints generates
+const MAX_GRID_POINTS = 1000000
+
+// MAX_POLYLINE_POINTS largest polyline DensifyPolyline returns
+const MAX_POLYLINE_POINTS = 1000000
+
+// DEFAULT_PRICE_PER_CALL list price in dollars of a geocoding request, $5 per 1000
+const DEFAULT_PRICE_PER_CALL = 0.005
+
+// DEFAULT_MAX_ADDRESS_LENGTH longest address string sent for geocoding
+const DEFAULT_MAX_ADDRESS_LENGTH = 2048
+
+const (
+	STATUS_OK           string = "OK"
+	STATUS_ZERO_RESULTS string = "ZERO_RESULTS"
+)
+
+const (
+	WARN_VEHICLE_UNSUPPORTED string = "truck routing unsupported, using default routing"
+)
+
 const (
-	ERROR_GEOCODING_POSTAL  string = "error geocoding postal code"
-	ERROR_GEOCODING_ADDRESS string = "error geocoding address"
-	ERROR_NO_FILE           string = "%s doesn't exist"
-	ERROR_FILE_INACCESSIBLE string = "%s inaccessible"
-	ERROR_CREATING_FILE     string = "creating file %s"
-	NO_RESULTS              string = "no results found"
-	ERR_INVALID_LAT_LNG     string = "invalid geo lat/lng"
-	ERR_INVALID_UNIT        string = "invalid geo distance unit"
+	ERROR_GEOCODING_POSTAL      string = "error geocoding postal code"
+	ERROR_GEOCODING_ADDRESS     string = "error geocoding address"
+	ERROR_TIMEZONE              string = "error fetching timezone"
+	ERROR_ELEVATION             string = "error fetching elevation"
+	ERROR_FINDING_PLACE         string = "error finding place"
+	ERROR_NO_FILE               string = "%s doesn't exist"
+	ERROR_FILE_INACCESSIBLE     string = "%s inaccessible"
+	ERROR_CREATING_FILE         string = "creating file %s"
+	NO_RESULTS                  string = "no results found"
+	ERR_INVALID_LAT_LNG         string = "invalid geo lat/lng"
+	ERR_INVALID_UNIT            string = "invalid geo distance unit"
+	ERR_NO_ROUTE                string = "no route found"
+	ERR_EMPTY_RESPONSE          string = "empty response"
+	ERR_NO_ROAD                 string = "no road found"
+	ERR_INVALID_POSTAL_CODE     string = "invalid postal code"
+	ERR_UNSUPPORTED_COUNTRY     string = "unsupported country"
+	ERR_DATUM_MISMATCH          string = "points use different datums"
+	ERR_UNSUPPORTED_DATUM       string = "unsupported datum conversion"
+	ERR_ADDRESS_TOO_LONG        string = "address too long"
+	ERR_INVALID_POLYGON         string = "invalid polygon"
+	ERR_INVALID_TRAVEL_MODE     string = "invalid travel mode"
+	ERR_INVALID_ADDRESS         string = "invalid address"
+	ERR_MISSING_COUNTRY         string = "missing country"
+	ERR_OFFLINE                 string = "offline, no cached result"
+	ERR_INVALID_GRANULARITY     string = "invalid granularity"
+	ERR_CACHE_DISABLED          string = "cache disabled"
+	ERR_INVALID_POLYLINE        string = "invalid polyline"
+	ERR_INVALID_BOUNDS          string = "invalid bounds"
+	ERR_INVALID_SPACING         string = "invalid spacing"
+	ERR_GRID_TOO_LARGE          string = "grid too large"
+	ERR_POLYLINE_TOO_LARGE      string = "densified polyline too large"
+	ERR_INVALID_MATRIX_INPUT    string = "matrix input needs exactly one of point or address"
+	ERR_SAME_ORIGIN_DESTINATION string = "origin and destination are the same"
+	ERR_UNSUPPORTED_BY_PROVIDER string = "api not supported by the configured provider"
+	ERR_PARALLEL_PATHS          string = "paths are parallel or coincident"
+	ERR_IMPLAUSIBLE_ROUTE       string = "routed distance shorter than straight-line distance"
+	ERR_INVALID_DISTANCE_METHOD string = "invalid distance method"
+	ERR_INVALID_UNITS           string = "invalid units"
+	ERR_INVALID_ADDRESS_FIELD   string = "invalid address field"
+	ERR_INVALID_TIE_BREAKER     string = "invalid tie breaker"
 )
 
 var (
-	ErrNilContext        = errors.NewAppError("context is nil")
-	ErrGeoCodePostalCode = errors.NewAppError(ERROR_GEOCODING_POSTAL)
-	ErrGeoCodeAddress    = errors.NewAppError(ERROR_GEOCODING_ADDRESS)
-	ErrGeoCodeNoResults  = errors.NewAppError(NO_RESULTS)
-	ErrInvalidGeoLatLng  = errors.NewAppError(ERR_INVALID_LAT_LNG)
-	ErrInvalidGeoUnit    = errors.NewAppError(ERR_INVALID_UNIT)
+	ErrNilContext            = errors.NewAppError("context is nil")
+	ErrGeoCodePostalCode     = errors.NewAppError(ERROR_GEOCODING_POSTAL)
+	ErrGeoCodeAddress        = errors.NewAppError(ERROR_GEOCODING_ADDRESS)
+	ErrTimezone              = errors.NewAppError(ERROR_TIMEZONE)
+	ErrElevation             = errors.NewAppError(ERROR_ELEVATION)
+	ErrFindPlace             = errors.NewAppError(ERROR_FINDING_PLACE)
+	ErrGeoCodeNoResults      = errors.NewAppError(NO_RESULTS)
+	ErrInvalidGeoLatLng      = errors.NewAppError(ERR_INVALID_LAT_LNG)
+	ErrInvalidGeoUnit        = errors.NewAppError(ERR_INVALID_UNIT)
+	ErrNoRoute               = errors.NewAppError(ERR_NO_ROUTE)
+	ErrEmptyResponse         = errors.NewAppError(ERR_EMPTY_RESPONSE)
+	ErrNoRoad                = errors.NewAppError(ERR_NO_ROAD)
+	ErrInvalidPostalCode     = errors.NewAppError(ERR_INVALID_POSTAL_CODE)
+	ErrUnsupportedCountry    = errors.NewAppError(ERR_UNSUPPORTED_COUNTRY)
+	ErrDatumMismatch         = errors.NewAppError(ERR_DATUM_MISMATCH)
+	ErrUnsupportedDatum      = errors.NewAppError(ERR_UNSUPPORTED_DATUM)
+	ErrAddressTooLong        = errors.NewAppError(ERR_ADDRESS_TOO_LONG)
+	ErrInvalidPolygon        = errors.NewAppError(ERR_INVALID_POLYGON)
+	ErrInvalidTravelMode     = errors.NewAppError(ERR_INVALID_TRAVEL_MODE)
+	ErrInvalidAddress        = errors.NewAppError(ERR_INVALID_ADDRESS)
+	ErrMissingCountry        = errors.NewAppError(ERR_MISSING_COUNTRY)
+	ErrOffline               = errors.NewAppError(ERR_OFFLINE)
+	ErrInvalidGranularity    = errors.NewAppError(ERR_INVALID_GRANULARITY)
+	ErrCacheDisabled         = errors.NewAppError(ERR_CACHE_DISABLED)
+	ErrInvalidPolyline       = errors.NewAppError(ERR_INVALID_POLYLINE)
+	ErrInvalidBounds         = errors.NewAppError(ERR_INVALID_BOUNDS)
+	ErrInvalidSpacing        = errors.NewAppError(ERR_INVALID_SPACING)
+	ErrGridTooLarge          = errors.NewAppError(ERR_GRID_TOO_LARGE)
+	ErrPolylineTooLarge      = errors.NewAppError(ERR_POLYLINE_TOO_LARGE)
+	ErrInvalidMatrixInput    = errors.NewAppError(ERR_INVALID_MATRIX_INPUT)
+	ErrSameOriginDestination = errors.NewAppError(ERR_SAME_ORIGIN_DESTINATION)
+	ErrUnsupportedByProvider = errors.NewAppError(ERR_UNSUPPORTED_BY_PROVIDER)
+	ErrParallelPaths         = errors.NewAppError(ERR_PARALLEL_PATHS)
+	ErrImplausibleRoute      = errors.NewAppError(ERR_IMPLAUSIBLE_ROUTE)
+	ErrInvalidDistanceMethod = errors.NewAppError(ERR_INVALID_DISTANCE_METHOD)
+	ErrInvalidUnits          = errors.NewAppError(ERR_INVALID_UNITS)
+	ErrInvalidAddressField   = errors.NewAppError(ERR_INVALID_ADDRESS_FIELD)
+	ErrInvalidTieBreaker     = errors.NewAppError(ERR_INVALID_TIE_BREAKER)
 )
@@ -0,0 +1,522 @@
+package geocode
+
+import (
+	"math"
+	"math/rand"
+
+	"gitlab.com/xerra/common/vincenty"
+)
+
+// PathLength returns the cumulative vincenty distance along an ordered path of points
+func PathLength(points []*Point, u DistanceUnit) (float64, error) {
+	if !u.isValid() {
+		return 0, ErrInvalidGeoUnit
+	}
+	for _, p := range points {
+		if p == nil || !p.IsValid() {
+			return 0, ErrInvalidGeoLatLng
+		}
+	}
+
+	total := 0.0
+	for i := 1; i < len(points); i++ {
+		d, err := distance(vincentyCalculator{}, u, points[i-1], points[i])
+		if err != nil {
+			return 0, err
+		}
+		total += d
+	}
+	return total, nil
+}
+
+// StandardDistance returns the spatial dispersion of points, the root mean square of their vincenty distances
+// to the centroid
+func StandardDistance(points []*Point, u DistanceUnit) (float64, error) {
+	if !u.isValid() {
+		return 0, ErrInvalidGeoUnit
+	}
+	c, err := centroid(points)
+	if err != nil {
+		return 0, err
+	}
+	c.Datum = points[0].Datum
+
+	sum := 0.0
+	for _, p := range points {
+		d, err := distance(vincentyCalculator{}, u, c, p)
+		if err != nil {
+			return 0, err
+		}
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(points))), nil
+}
+
+// FindDuplicatePoints groups the indices of points lying within tolerance meters of each other,
+// only groups with more than one point are returned, nil or invalid points are ignored
+func FindDuplicatePoints(points []*Point, tolerance float64) [][]int {
+	parent := make([]int, len(points))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	for i := 0; i < len(points); i++ {
+		for j := i + 1; j < len(points); j++ {
+			d, err := distance(vincentyCalculator{}, METERS, points[i], points[j])
+			if err != nil || d > tolerance {
+				continue
+			}
+			if ri, rj := find(i), find(j); ri != rj {
+				parent[rj] = ri
+			}
+		}
+	}
+
+	members := map[int][]int{}
+	roots := []int{}
+	for i := range points {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], i)
+	}
+
+	groups := [][]int{}
+	for _, r := range roots {
+		if len(members[r]) > 1 {
+			groups = append(groups, members[r])
+		}
+	}
+	return groups
+}
+
+// DistanceCalculator computes the distance in meters between two coordinates
+type DistanceCalculator interface {
+	Meters(lat1, lng1, lat2, lng2 float64) float64
+}
+
+// vincentyCalculator is the default ellipsoidal distance calculator
+type vincentyCalculator struct{}
+
+func (vincentyCalculator) Meters(lat1, lng1, lat2, lng2 float64) float64 {
+	origin := vincenty.LatLng{Latitude: lat1, Longitude: lng1}
+	end := vincenty.LatLng{Latitude: lat2, Longitude: lng2}
+	return vincenty.Inverse(origin, end).Meters()
+}
+
+// haversineCalculator great circle distance on a sphere of the given radius
+type haversineCalculator struct {
+	radius float64
+}
+
+func (h haversineCalculator) Meters(lat1, lng1, lat2, lng2 float64) float64 {
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * h.radius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+func distance(calc DistanceCalculator, u DistanceUnit, source, dest *Point) (float64, error) {
+	if source == nil || dest == nil || !source.IsValid() || !dest.IsValid() {
+		return 0, ErrInvalidGeoLatLng
+	}
+	if source.GetDatum() != dest.GetDatum() {
+		return 0, ErrDatumMismatch
+	}
+
+	m := calc.Meters(source.Latitude, source.Longitude, dest.Latitude, dest.Longitude)
+	return fromMeters(m, u)
+}
+
+func fromMeters(m float64, u DistanceUnit) (float64, error) {
+	switch u {
+	case KM:
+		return m / 1000, nil
+	case MILES:
+		return m / 1609.344, nil
+	case METERS:
+		return m, nil
+	case FEET:
+		return m / 0.3048, nil
+	default:
+		return 0, ErrInvalidGeoUnit
+	}
+}
+
+func toMeters(d float64, u DistanceUnit) (float64, error) {
+	switch u {
+	case KM:
+		return d * 1000, nil
+	case MILES:
+		return d * 1609.344, nil
+	case METERS:
+		return d, nil
+	case FEET:
+		return d * 0.3048, nil
+	default:
+		return 0, ErrInvalidGeoUnit
+	}
+}
+
+// GridPoints returns points evenly spaced spacing apart, in unit u, across bounds row by row from the south west corner,
+// longitude spacing is measured at the box's middle latitude and boxes crossing the antimeridian aren't supported
+func GridPoints(bounds RangeBounds, spacing float64, u DistanceUnit) ([]*Point, error) {
+	lat, lng := bounds.Latitude, bounds.Longitude
+	if !finite(lat.Min, lat.Max, lng.Min, lng.Max) || lat.Min > lat.Max || lng.Min > lng.Max ||
+		lat.Min < -90 || lat.Max > 90 || lng.Min < -180 || lng.Max > 180 {
+		return nil, ErrInvalidBounds
+	}
+	if !u.isValid() {
+		return nil, ErrInvalidGeoUnit
+	}
+	if !finite(spacing) || spacing <= 0 {
+		return nil, ErrInvalidSpacing
+	}
+	m, err := toMeters(spacing, u)
+	if err != nil {
+		return nil, err
+	}
+
+	metersPerDeg := EARTH_RADIUS_METERS * math.Pi / 180
+	latStep := m / metersPerDeg
+	lngStep := latStep / math.Max(math.Cos(toRadians((lat.Min+lat.Max)/2)), 1e-9)
+
+	// tolerate rounding so edges exactly a multiple of spacing away are included,
+	// counted as floats as a tiny spacing overflows int
+	fRows := math.Floor((lat.Max-lat.Min)/latStep+1e-6) + 1
+	fCols := math.Floor((lng.Max-lng.Min)/lngStep+1e-6) + 1
+	if !finite(fRows, fCols) || fRows*fCols > MAX_GRID_POINTS {
+		return nil, ErrGridTooLarge
+	}
+
+	rows, cols := int(fRows), int(fCols)
+	points := make([]*Point, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			points = append(points, &Point{
+				Latitude:  lat.Min + float64(r)*latStep,
+				Longitude: lng.Min + float64(c)*lngStep,
+			})
+		}
+	}
+	return points, nil
+}
+
+// DensifyPolyline returns points with great circle interpolated points inserted so no segment is longer than
+// maxSpacing in unit u, measured on a sphere of EARTH_RADIUS_METERS, at most MAX_POLYLINE_POINTS points
+func DensifyPolyline(points []LatLng, maxSpacing float64, u DistanceUnit) ([]LatLng, error) {
+	if !u.isValid() {
+		return nil, ErrInvalidGeoUnit
+	}
+	if !finite(maxSpacing) || maxSpacing <= 0 {
+		return nil, ErrInvalidSpacing
+	}
+	m, err := toMeters(maxSpacing, u)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range points {
+		if !finite(p.Lat, p.Lng) || p.Lat < -90 || p.Lat > 90 || p.Lng < -180 || p.Lng > 180 {
+			return nil, ErrInvalidGeoLatLng
+		}
+	}
+	if len(points) < 2 {
+		return append([]LatLng{}, points...), nil
+	}
+
+	// segment counts are summed as floats before anything is allocated, a tiny spacing overflows int
+	calc := haversineCalculator{radius: EARTH_RADIUS_METERS}
+	dists := make([]float64, len(points)-1)
+	total := 1.0
+	for i := 1; i < len(points); i++ {
+		a, b := points[i-1], points[i]
+		dists[i-1] = calc.Meters(a.Lat, a.Lng, b.Lat, b.Lng)
+		total += math.Max(math.Ceil(dists[i-1]/m), 1)
+	}
+	if !finite(total) || total > MAX_POLYLINE_POINTS {
+		return nil, ErrPolylineTooLarge
+	}
+
+	dense := make([]LatLng, 0, int(total))
+	dense = append(dense, points[0])
+	for i := 1; i < len(points); i++ {
+		a, b, d := points[i-1], points[i], dists[i-1]
+		n := int(math.Ceil(d / m))
+		for k := 1; k < n; k++ {
+			dense = append(dense, interpolate(a, b, d/EARTH_RADIUS_METERS, float64(k)/float64(n)))
+		}
+		dense = append(dense, b)
+	}
+	return dense, nil
+}
+
+// finite reports whether none of vs is NaN or infinite
+func finite(vs ...float64) bool {
+	for _, v := range vs {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+// interpolate returns the point at fraction f along the great circle from a to b, delta is their angular distance
+func interpolate(a, b LatLng, delta, f float64) LatLng {
+	lat1, lng1 := toRadians(a.Lat), toRadians(a.Lng)
+	lat2, lng2 := toRadians(b.Lat), toRadians(b.Lng)
+	sd := math.Sin(delta)
+	if sd < 1e-12 {
+		return a
+	}
+	wa, wb := math.Sin((1-f)*delta)/sd, math.Sin(f*delta)/sd
+
+	x := wa*math.Cos(lat1)*math.Cos(lng1) + wb*math.Cos(lat2)*math.Cos(lng2)
+	y := wa*math.Cos(lat1)*math.Sin(lng1) + wb*math.Cos(lat2)*math.Sin(lng2)
+	z := wa*math.Sin(lat1) + wb*math.Sin(lat2)
+	return LatLng{
+		Lat: toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Lng: toDegrees(math.Atan2(y, x)),
+	}
+}
+
+// PathIntersection returns where the great circle through a1 and a2 crosses the one through b1 and b2,
+// of the circles' two antipodal crossings the one nearest the paths is returned
+func PathIntersection(a1, a2, b1, b2 *Point) (*Point, error) {
+	for _, p := range []*Point{a1, a2, b1, b2} {
+		if p == nil || !p.IsValid() {
+			return nil, ErrInvalidGeoLatLng
+		}
+		if p.GetDatum() != a1.GetDatum() {
+			return nil, ErrDatumMismatch
+		}
+	}
+
+	na := cross(unitVector(a1), unitVector(a2))
+	nb := cross(unitVector(b1), unitVector(b2))
+	if norm(na) < 1e-12 || norm(nb) < 1e-12 {
+		// a path's points are the same or antipodal, its great circle is undefined
+		return nil, ErrInvalidGeoLatLng
+	}
+	i := cross(na, nb)
+	if norm(i) < 1e-12 {
+		return nil, ErrParallelPaths
+	}
+
+	var mid [3]float64
+	for _, p := range []*Point{a1, a2, b1, b2} {
+		v := unitVector(p)
+		for k := range mid {
+			mid[k] += v[k]
+		}
+	}
+	if dot(i, mid) < 0 {
+		i = [3]float64{-i[0], -i[1], -i[2]}
+	}
+
+	return &Point{
+		Latitude:  toDegrees(math.Atan2(i[2], math.Sqrt(i[0]*i[0]+i[1]*i[1]))),
+		Longitude: toDegrees(math.Atan2(i[1], i[0])),
+		Datum:     a1.Datum,
+	}, nil
+}
+
+func unitVector(p *Point) [3]float64 {
+	lat, lng := toRadians(p.Latitude), toRadians(p.Longitude)
+	return [3]float64{math.Cos(lat) * math.Cos(lng), math.Cos(lat) * math.Sin(lng), math.Sin(lat)}
+}
+
+func cross(a, b [3]float64) [3]float64 {
+	return [3]float64{
+		a[1]*b[2] - a[2]*b[1],
+		a[2]*b[0] - a[0]*b[2],
+		a[0]*b[1] - a[1]*b[0],
+	}
+}
+
+func dot(a, b [3]float64) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+func norm(a [3]float64) float64 {
+	return math.Sqrt(dot(a, a))
+}
+
+// DetourRatio returns the leg's routed distance over the straight-line distance between start and end,
+// a ratio below 1 is physically impossible and signals bad routing data
+func (l *RouteLeg) DetourRatio(start, end *Point) (float64, error) {
+	straight, err := distance(vincentyCalculator{}, METERS, start, end)
+	if err != nil {
+		return 0, err
+	}
+	if straight == 0 {
+		return 0, ErrSameOriginDestination
+	}
+
+	ratio := float64(l.Distance) / straight
+	if ratio < 1 {
+		return ratio, ErrImplausibleRoute
+	}
+	return ratio, nil
+}
+
+// BoundingCircle returns the center of the smallest circle enclosing points and its radius in meters.
+// The circle is found with Welzl's algorithm on an equirectangular projection around the points' centroid,
+// so it's meant for regions up to a few hundred kilometers, the radius is the largest vincenty distance
+// from the center to any of the points.
+func BoundingCircle(points []*Point) (*Point, float64, error) {
+	origin, err := centroid(points)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, p := range points {
+		if p.GetDatum() != points[0].GetDatum() {
+			return nil, 0, ErrDatumMismatch
+		}
+	}
+	cosLat := math.Cos(toRadians(origin.Latitude))
+	if cosLat < 1e-9 {
+		// the projection degenerates at the poles
+		return nil, 0, ErrInvalidGeoLatLng
+	}
+
+	projected := make([][2]float64, len(points))
+	for i, p := range points {
+		projected[i] = [2]float64{
+			toRadians(wrapLongitude(p.Longitude-origin.Longitude)) * cosLat * EARTH_RADIUS_METERS,
+			toRadians(p.Latitude-origin.Latitude) * EARTH_RADIUS_METERS,
+		}
+	}
+	// welzl's expected linear time relies on a random insertion order
+	rand.Shuffle(len(projected), func(i, j int) {
+		projected[i], projected[j] = projected[j], projected[i]
+	})
+	c := enclosingCircle(projected)
+
+	center := &Point{
+		Latitude:  origin.Latitude + toDegrees(c.y/EARTH_RADIUS_METERS),
+		Longitude: wrapLongitude(origin.Longitude + toDegrees(c.x/(cosLat*EARTH_RADIUS_METERS))),
+		Datum:     points[0].Datum,
+	}
+	radius := 0.0
+	for _, p := range points {
+		radius = math.Max(radius, vincentyCalculator{}.Meters(center.Latitude, center.Longitude, p.Latitude, p.Longitude))
+	}
+	return center, radius, nil
+}
+
+// planarCircle circle on the projected plane, in meters
+type planarCircle struct {
+	x, y, r float64
+}
+
+func (c planarCircle) contains(p [2]float64) bool {
+	// allow for rounding, a millimeter
+	return math.Hypot(p[0]-c.x, p[1]-c.y) <= c.r+1e-3
+}
+
+// enclosingCircle iterative form of welzl's minimal enclosing circle of at least one point
+func enclosingCircle(pts [][2]float64) planarCircle {
+	c := planarCircle{x: pts[0][0], y: pts[0][1]}
+	for i := 1; i < len(pts); i++ {
+		if c.contains(pts[i]) {
+			continue
+		}
+		c = planarCircle{x: pts[i][0], y: pts[i][1]}
+		for j := 0; j < i; j++ {
+			if c.contains(pts[j]) {
+				continue
+			}
+			c = circleFrom2(pts[i], pts[j])
+			for k := 0; k < j; k++ {
+				if !c.contains(pts[k]) {
+					c = circleFrom3(pts[i], pts[j], pts[k])
+				}
+			}
+		}
+	}
+	return c
+}
+
+// circleFrom2 circle with a and b as its diameter
+func circleFrom2(a, b [2]float64) planarCircle {
+	return planarCircle{
+		x: (a[0] + b[0]) / 2,
+		y: (a[1] + b[1]) / 2,
+		r: math.Hypot(a[0]-b[0], a[1]-b[1]) / 2,
+	}
+}
+
+// circleFrom3 circumcircle of a, b and c, or the widest pair's circle when they're collinear
+func circleFrom3(a, b, c [2]float64) planarCircle {
+	bx, by := b[0]-a[0], b[1]-a[1]
+	cx, cy := c[0]-a[0], c[1]-a[1]
+	d := 2 * (bx*cy - by*cx)
+	if math.Abs(d) < 1e-12 {
+		widest := circleFrom2(a, b)
+		for _, w := range []planarCircle{circleFrom2(a, c), circleFrom2(b, c)} {
+			if w.r > widest.r {
+				widest = w
+			}
+		}
+		return widest
+	}
+	ux := (cy*(bx*bx+by*by) - by*(cx*cx+cy*cy)) / d
+	uy := (bx*(cx*cx+cy*cy) - cx*(bx*bx+by*by)) / d
+	return planarCircle{x: a[0] + ux, y: a[1] + uy, r: math.Hypot(ux, uy)}
+}
+
+// wrapLongitude normalizes a longitude, or longitude difference, to [-180, 180)
+func wrapLongitude(lng float64) float64 {
+	lng = math.Mod(lng+180, 360)
+	if lng < 0 {
+		lng += 360
+	}
+	return lng - 180
+}
+
+// centroid returns the spherical centroid of points, the normalized mean of their unit vectors
+func centroid(points []*Point) (*Point, error) {
+	if len(points) < 1 {
+		return nil, ErrInvalidGeoLatLng
+	}
+
+	var x, y, z float64
+	for _, p := range points {
+		if p == nil || !p.IsValid() {
+			return nil, ErrInvalidGeoLatLng
+		}
+		lat, lng := toRadians(p.Latitude), toRadians(p.Longitude)
+		x += math.Cos(lat) * math.Cos(lng)
+		y += math.Cos(lat) * math.Sin(lng)
+		z += math.Sin(lat)
+	}
+
+	n := float64(len(points))
+	x, y, z = x/n, y/n, z/n
+	if math.Sqrt(x*x+y*y+z*z) < 1e-9 {
+		// points cancel out, e.g. antipodal pairs
+		return nil, ErrInvalidGeoLatLng
+	}
+
+	return &Point{
+		Latitude:  toDegrees(math.Atan2(z, math.Sqrt(x*x+y*y))),
+		Longitude: toDegrees(math.Atan2(y, x)),
+	}, nil
+}
+
+func toRadians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+func toDegrees(rad float64) float64 {
+	return rad * 180 / math.Pi
+}
@@ -0,0 +1,497 @@
+package geocode
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestDistance(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"path length of a triangle, succeeds":    testPathLength,
+		"path length validation, fails":          testPathLengthValidation,
+		"find duplicate points, succeeds":        testFindDuplicatePoints,
+		"custom distance calculator, succeeds":   testDistanceCalculator,
+		"bulk distances, succeeds":               testGetDistances,
+		"datum mismatch, fails":                  testDatumMismatch,
+		"custom sphere radius, succeeds":         testSphereRadius,
+		"grid points over a box, succeeds":       testGridPoints,
+		"standard distance, succeeds":            testStandardDistance,
+		"distance between addresses, succeeds":   testDistanceBetweenAddresses,
+		"densify polyline, succeeds":             testDensifyPolyline,
+		"path intersection, succeeds":            testPathIntersection,
+		"metric and imperial distance, succeeds": testDistanceDual,
+		"route leg detour ratio, succeeds":       testDetourRatio,
+		"haversine and vincenty agree, succeeds": testDistanceMethods,
+		"bounding circle of a cluster, succeeds": testBoundingCircle,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testPathLength(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	a := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	b := &Point{Latitude: 37.8044, Longitude: -122.2712}
+	c := &Point{Latitude: 37.6879, Longitude: -122.4702}
+
+	sides := 0.0
+	for _, pair := range [][2]*Point{{a, b}, {b, c}, {c, a}} {
+		d, err := gsc.GetDistance(ctx, KM, pair[0], pair[1])
+		require.NoError(t, err)
+		sides += d
+	}
+
+	perimeter, err := PathLength([]*Point{a, b, c, a}, KM)
+	require.NoError(t, err)
+	require.InDelta(t, sides, perimeter, 1e-9)
+
+	l, err := PathLength([]*Point{a}, KM)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, l)
+}
+
+func testPathLengthValidation(t *testing.T) {
+	a := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	b := &Point{Latitude: 37.8044, Longitude: -122.2712}
+
+	_, err := PathLength([]*Point{a, b}, DistanceUnit("LEAGUES"))
+	require.Equal(t, ErrInvalidGeoUnit, err)
+
+	_, err = PathLength([]*Point{a, nil, b}, KM)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
+
+func testFindDuplicatePoints(t *testing.T) {
+	points := []*Point{
+		{Latitude: 37.774900, Longitude: -122.419400},
+		{Latitude: 37.804400, Longitude: -122.271200},
+		{Latitude: 37.774910, Longitude: -122.419410},
+		nil,
+	}
+
+	groups := FindDuplicatePoints(points, 10)
+	require.Equal(t, [][]int{{0, 2}}, groups)
+
+	groups = FindDuplicatePoints(points, 0.1)
+	require.Empty(t, groups)
+}
+
+type stubCalculator struct {
+	calls int
+}
+
+func (c *stubCalculator) Meters(lat1, lng1, lat2, lng2 float64) float64 {
+	c.calls++
+	return 1234
+}
+
+func testDistanceCalculator(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calc := &stubCalculator{}
+	gsc := newFakeService(t, Config{DistanceCalculator: calc}, &fakeMapsClient{})
+
+	a := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	b := &Point{Latitude: 37.8044, Longitude: -122.2712}
+
+	d, err := gsc.GetDistance(ctx, METERS, a, b)
+	require.NoError(t, err)
+	require.Equal(t, 1234.0, d)
+
+	d, err = gsc.GetDistance(ctx, KM, a, b)
+	require.NoError(t, err)
+	require.Equal(t, 1.234, d)
+	require.Equal(t, 2, calc.calls)
+}
+
+func testGetDistances(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	sf := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	oak := &Point{Latitude: 37.8044, Longitude: -122.2712}
+	sj := &Point{Latitude: 37.3382, Longitude: -121.8863}
+	pairs := [][2]*Point{{sf, oak}, {oak, sj}, {sj, sf}}
+
+	ds, err := gsc.GetDistances(ctx, MILES, pairs)
+	require.NoError(t, err)
+	require.Equal(t, len(pairs), len(ds))
+	for i, pair := range pairs {
+		d, err := gsc.GetDistance(ctx, MILES, pair[0], pair[1])
+		require.NoError(t, err)
+		require.Equal(t, d, ds[i])
+	}
+
+	_, err = gsc.GetDistances(ctx, DistanceUnit("LEAGUES"), pairs)
+	require.Equal(t, ErrInvalidGeoUnit, err)
+
+	_, err = gsc.GetDistances(ctx, KM, [][2]*Point{{sf, nil}})
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
+
+func testDatumMismatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	a := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	b := &Point{Latitude: 37.8044, Longitude: -122.2712, Datum: WGS84}
+	require.Equal(t, WGS84, a.GetDatum())
+
+	_, err := gsc.GetDistance(ctx, KM, a, b)
+	require.NoError(t, err)
+
+	c := &Point{Latitude: 37.6879, Longitude: -122.4702, Datum: NAD83}
+	_, err = gsc.GetDistance(ctx, KM, a, c)
+	require.Equal(t, ErrDatumMismatch, err)
+
+	_, err = PathLength([]*Point{a, b, c}, KM)
+	require.Equal(t, ErrDatumMismatch, err)
+
+	_, err = c.ToDatum(WGS84)
+	require.Equal(t, ErrUnsupportedDatum, err)
+
+	cp, err := a.ToDatum(WGS84)
+	require.NoError(t, err)
+	require.Equal(t, WGS84, cp.Datum)
+}
+
+func testSphereRadius(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	b := &Point{Latitude: 34.0522, Longitude: -118.2437}
+
+	earth := newFakeService(t, Config{SphereRadiusMeters: EARTH_RADIUS_METERS}, &fakeMapsClient{})
+	d, err := earth.GetDistance(ctx, KM, a, b)
+	require.NoError(t, err)
+	require.InDelta(t, 559, d, 2)
+
+	doubled := newFakeService(t, Config{SphereRadiusMeters: 2 * EARTH_RADIUS_METERS}, &fakeMapsClient{})
+	d2, err := doubled.GetDistance(ctx, KM, a, b)
+	require.NoError(t, err)
+	require.InDelta(t, 2*d, d2, 1e-6)
+}
+
+func testGridPoints(t *testing.T) {
+	metersPerDeg := EARTH_RADIUS_METERS * math.Pi / 180
+	// 0.9 km tall and wide box at the equator, 300m spacing
+	side := 900 / metersPerDeg
+	box := RangeBounds{
+		Latitude:  Range{Min: 0, Max: side},
+		Longitude: Range{Min: 10, Max: 10 + side},
+	}
+
+	pts, err := GridPoints(box, 0.3, KM)
+	require.NoError(t, err)
+	require.Equal(t, 16, len(pts))
+	require.Equal(t, 0.0, pts[0].Latitude)
+	require.Equal(t, 10.0, pts[0].Longitude)
+	require.InDelta(t, side, pts[15].Latitude, 1e-9)
+	require.InDelta(t, 10+side, pts[15].Longitude, 1e-6)
+
+	rows := map[float64]int{}
+	for _, p := range pts {
+		rows[p.Latitude]++
+	}
+	require.Equal(t, 4, len(rows))
+
+	_, err = GridPoints(box, 0, KM)
+	require.Equal(t, ErrInvalidSpacing, err)
+	_, err = GridPoints(RangeBounds{Latitude: Range{Min: 1, Max: 0}}, 1, KM)
+	require.Equal(t, ErrInvalidBounds, err)
+	_, err = GridPoints(box, 1, DistanceUnit("LEAGUES"))
+	require.Equal(t, ErrInvalidGeoUnit, err)
+	_, err = GridPoints(box, 1e-6, METERS)
+	require.Equal(t, ErrGridTooLarge, err)
+	_, err = GridPoints(RangeBounds{Latitude: Range{Min: -90, Max: 90}}, 1e-300, METERS)
+	require.Equal(t, ErrGridTooLarge, err)
+	_, err = GridPoints(box, math.NaN(), METERS)
+	require.Equal(t, ErrInvalidSpacing, err)
+	_, err = GridPoints(box, math.Inf(1), METERS)
+	require.Equal(t, ErrInvalidSpacing, err)
+	_, err = GridPoints(RangeBounds{Latitude: Range{Min: math.NaN(), Max: 1}}, 1, KM)
+	require.Equal(t, ErrInvalidBounds, err)
+}
+
+func testStandardDistance(t *testing.T) {
+	// four points 1km north, south, east and west of a center are all ~1km from the centroid
+	lat, lng := 37.7749, -122.4194
+	dLat := 1000 / EARTH_RADIUS_METERS * 180 / math.Pi
+	dLng := dLat / math.Cos(lat*math.Pi/180)
+	spread := []*Point{
+		{Latitude: lat + dLat, Longitude: lng},
+		{Latitude: lat - dLat, Longitude: lng},
+		{Latitude: lat, Longitude: lng + dLng},
+		{Latitude: lat, Longitude: lng - dLng},
+	}
+	sd, err := StandardDistance(spread, METERS)
+	require.NoError(t, err)
+	// offsets are on a sphere, distances on the ellipsoid
+	require.InDelta(t, 1000, sd, 10)
+
+	tight := []*Point{
+		{Latitude: 37.77490, Longitude: -122.41940},
+		{Latitude: 37.77491, Longitude: -122.41941},
+		{Latitude: 37.77489, Longitude: -122.41939},
+	}
+	tsd, err := StandardDistance(tight, METERS)
+	require.NoError(t, err)
+	require.Less(t, tsd, 2.0)
+
+	one, err := StandardDistance(tight[:1], METERS)
+	require.NoError(t, err)
+	require.InDelta(t, 0, one, 1e-6)
+
+	_, err = StandardDistance(nil, METERS)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+	_, err = StandardDistance(tight, DistanceUnit("LEAGUES"))
+	require.Equal(t, ErrInvalidGeoUnit, err)
+}
+
+func testDistanceBetweenAddresses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			switch {
+			case strings.Contains(r.Address, "San Francisco"):
+				return []maps.GeocodingResult{fakeResult(37.7793, -122.4193, "1 Dr Carlton B Goodlett Pl, San Francisco, CA 94102, USA", "street_address")}, nil
+			case strings.Contains(r.Address, "Oakland"):
+				return []maps.GeocodingResult{fakeResult(37.8053, -122.2725, "1 Frank H. Ogawa Plaza, Oakland, CA 94612, USA", "street_address")}, nil
+			}
+			return nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	sf := &AddressQuery{Street: "1 Dr Carlton B Goodlett Pl", City: "San Francisco", State: "CA"}
+	oak := &AddressQuery{Street: "1 Frank H. Ogawa Plaza", City: "Oakland", State: "CA"}
+	d, err := gsc.GetDistanceBetweenAddresses(ctx, KM, sf, oak)
+	require.NoError(t, err)
+	require.InDelta(t, 13.2, d, 0.5)
+	require.Equal(t, 2, c.geocodeCalls())
+
+	d, err = gsc.GetDistanceBetweenAddresses(ctx, KM, sf, sf)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, d)
+	require.Equal(t, 3, c.geocodeCalls())
+
+	// the same address spelled differently is geocoded once, and neither query is modified
+	sfUpper := &AddressQuery{Street: "1 DR CARLTON B GOODLETT PL", City: "SAN FRANCISCO", State: "CA", Country: "usa"}
+	d, err = gsc.GetDistanceBetweenAddresses(ctx, KM, sf, sfUpper)
+	require.NoError(t, err)
+	require.Equal(t, 0.0, d)
+	require.Equal(t, 4, c.geocodeCalls())
+	require.Equal(t, "", sf.Country)
+	require.Equal(t, "usa", sfUpper.Country)
+
+	_, err = gsc.GetDistanceBetweenAddresses(ctx, KM, sf, &AddressQuery{City: "Nowhere"})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+}
+
+func testDensifyPolyline(t *testing.T) {
+	sparse := []LatLng{
+		{Lat: 37.7749, Lng: -122.4194},
+		{Lat: 37.8044, Lng: -122.2712},
+		{Lat: 37.8045, Lng: -122.2711},
+		{Lat: 38.5816, Lng: -121.4944},
+	}
+	calc := haversineCalculator{radius: EARTH_RADIUS_METERS}
+
+	dense, err := DensifyPolyline(sparse, 500, METERS)
+	require.NoError(t, err)
+	require.Greater(t, len(dense), 200)
+	require.Equal(t, sparse[0], dense[0])
+	require.Equal(t, sparse[len(sparse)-1], dense[len(dense)-1])
+	for i := 1; i < len(dense); i++ {
+		d := calc.Meters(dense[i-1].Lat, dense[i-1].Lng, dense[i].Lat, dense[i].Lng)
+		require.LessOrEqual(t, d, 500+1e-6)
+	}
+
+	// short segments are kept as is
+	dense, err = DensifyPolyline(sparse[1:3], 1, KM)
+	require.NoError(t, err)
+	require.Equal(t, sparse[1:3], dense)
+
+	_, err = DensifyPolyline(sparse, 0, KM)
+	require.Equal(t, ErrInvalidSpacing, err)
+	_, err = DensifyPolyline(sparse, math.NaN(), KM)
+	require.Equal(t, ErrInvalidSpacing, err)
+	_, err = DensifyPolyline(sparse, 1, DistanceUnit("LEAGUES"))
+	require.Equal(t, ErrInvalidGeoUnit, err)
+	_, err = DensifyPolyline([]LatLng{{Lat: 0, Lng: 0}, {Lat: math.NaN(), Lng: 0}}, 1, KM)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+
+	// 1mm spacing over a degree would be over 111 million points
+	_, err = DensifyPolyline([]LatLng{{Lat: 0, Lng: 0}, {Lat: 1, Lng: 0}}, 1e-3, METERS)
+	require.Equal(t, ErrPolylineTooLarge, err)
+}
+
+func testPathIntersection(t *testing.T) {
+	p, err := PathIntersection(
+		&Point{Latitude: 10, Longitude: -10}, &Point{Latitude: -10, Longitude: 10},
+		&Point{Latitude: -10, Longitude: -10}, &Point{Latitude: 10, Longitude: 10},
+	)
+	require.NoError(t, err)
+	require.InDelta(t, 0, p.Latitude, 1e-9)
+	require.InDelta(t, 0, p.Longitude, 1e-9)
+
+	// equator and the 5th meridian, the crossing near the paths rather than its antipode
+	p, err = PathIntersection(
+		&Point{Latitude: 0, Longitude: -10}, &Point{Latitude: 0, Longitude: 10},
+		&Point{Latitude: 40, Longitude: 5}, &Point{Latitude: 20, Longitude: 5},
+	)
+	require.NoError(t, err)
+	require.InDelta(t, 0, p.Latitude, 1e-9)
+	require.InDelta(t, 5, p.Longitude, 1e-9)
+
+	_, err = PathIntersection(
+		&Point{Latitude: 0, Longitude: -10}, &Point{Latitude: 0, Longitude: 10},
+		&Point{Latitude: 0, Longitude: 20}, &Point{Latitude: 0, Longitude: 30},
+	)
+	require.Equal(t, ErrParallelPaths, err)
+
+	_, err = PathIntersection(
+		&Point{Latitude: 0, Longitude: 10}, &Point{Latitude: 0, Longitude: 10},
+		&Point{Latitude: 0, Longitude: 20}, &Point{Latitude: 10, Longitude: 20},
+	)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
+
+func testDistanceDual(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+	src := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	dest := &Point{Latitude: 37.8044, Longitude: -122.2712}
+
+	d, err := gsc.GetDistanceDual(ctx, src, dest)
+	require.NoError(t, err)
+	m, err := gsc.GetDistance(ctx, METERS, src, dest)
+	require.NoError(t, err)
+	require.Equal(t, m, d.Meters)
+	require.InDelta(t, d.Meters/1000, d.Kilometers, 1e-9)
+	require.InDelta(t, d.Kilometers/1.609344, d.Miles, 1e-9)
+	require.InDelta(t, d.Miles*5280, d.Feet, 1e-6)
+
+	_, err = gsc.GetDistanceDual(ctx, src, nil)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
+
+func testDetourRatio(t *testing.T) {
+	petaluma := &Point{Latitude: 38.2324, Longitude: -122.6367}
+	novato := &Point{Latitude: 38.1074, Longitude: -122.5697}
+
+	// US-101 S, about 15 km as the crow flies
+	ratio, err := (&RouteLeg{Start: "Petaluma, CA", End: "Novato, CA", Distance: 19000}).DetourRatio(petaluma, novato)
+	require.NoError(t, err)
+	require.Greater(t, ratio, 1.0)
+	require.Less(t, ratio, 1.5)
+
+	ratio, err = (&RouteLeg{Distance: 9000}).DetourRatio(petaluma, novato)
+	require.Equal(t, ErrImplausibleRoute, err)
+	require.Less(t, ratio, 1.0)
+
+	_, err = (&RouteLeg{Distance: 9000}).DetourRatio(petaluma, petaluma)
+	require.Equal(t, ErrSameOriginDestination, err)
+
+	_, err = (&RouteLeg{Distance: 9000}).DetourRatio(petaluma, nil)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
+
+func testDistanceMethods(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	for _, pair := range [][2]*Point{
+		{{Latitude: 37.7749, Longitude: -122.4194}, {Latitude: 34.0522, Longitude: -118.2437}}, // san francisco, los angeles
+		{{Latitude: 40.7128, Longitude: -74.0060}, {Latitude: 51.5074, Longitude: -0.1278}},    // new york, london
+		{{Latitude: -33.8688, Longitude: 151.2093}, {Latitude: 35.6762, Longitude: 139.6503}},  // sydney, tokyo
+	} {
+		v, err := gsc.GetDistanceWithMethod(ctx, VINCENTY, KM, pair[0], pair[1])
+		require.NoError(t, err)
+		h, err := gsc.GetDistanceWithMethod(ctx, HAVERSINE, KM, pair[0], pair[1])
+		require.NoError(t, err)
+		require.InEpsilon(t, v, h, 0.005)
+
+		d, err := gsc.GetDistance(ctx, KM, pair[0], pair[1])
+		require.NoError(t, err)
+		require.Equal(t, v, d)
+	}
+
+	_, err := gsc.GetDistanceWithMethod(ctx, DistanceMethod("EUCLIDEAN"), KM, &Point{}, &Point{Latitude: 1})
+	require.Equal(t, ErrInvalidDistanceMethod, err)
+	_, err = gsc.GetDistanceWithMethod(ctx, HAVERSINE, DistanceUnit("LEAGUES"), &Point{}, &Point{Latitude: 1})
+	require.Equal(t, ErrInvalidGeoUnit, err)
+}
+
+func testBoundingCircle(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	cluster := []*Point{
+		{Latitude: 37.7749, Longitude: -122.4194},
+		{Latitude: 37.7793, Longitude: -122.4192},
+		{Latitude: 37.8044, Longitude: -122.2712},
+		{Latitude: 37.6879, Longitude: -122.4702},
+		{Latitude: 37.7599, Longitude: -122.4148},
+		{Latitude: 37.7337, Longitude: -122.3800},
+	}
+	center, radius, err := BoundingCircle(cluster)
+	require.NoError(t, err)
+	farthest := 0.0
+	for _, p := range cluster {
+		d, err := gsc.GetDistance(ctx, METERS, center, p)
+		require.NoError(t, err)
+		require.LessOrEqual(t, d, radius)
+		if d > farthest {
+			farthest = d
+		}
+	}
+	require.Equal(t, farthest, radius)
+
+	// no smaller than half the widest pair's distance, nor much larger for a cluster spanning it
+	span, err := gsc.GetDistance(ctx, METERS, cluster[2], cluster[3])
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, radius, span/2)
+	require.Less(t, radius, span/2*1.05)
+
+	// a pair's circle is centered between them, across the antimeridian too
+	pair := []*Point{{Latitude: 0, Longitude: 179.9}, {Latitude: 0, Longitude: -179.9}}
+	center, radius, err = BoundingCircle(pair)
+	require.NoError(t, err)
+	require.InDelta(t, 0, center.Latitude, 1e-9)
+	require.InDelta(t, 180, math.Abs(center.Longitude), 1e-9)
+	span, err = gsc.GetDistance(ctx, METERS, pair[0], pair[1])
+	require.NoError(t, err)
+	require.InDelta(t, span/2, radius, 1)
+
+	center, radius, err = BoundingCircle(cluster[:1])
+	require.NoError(t, err)
+	require.InDelta(t, cluster[0].Latitude, center.Latitude, 1e-9)
+	require.Equal(t, 0.0, radius)
+
+	_, _, err = BoundingCircle(nil)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
@@ -0,0 +1,82 @@
+package geocode
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+	"googlemaps.github.io/maps"
+)
+
+// EnrichOptions toggles the lookups GeocodeEnriched performs after geocoding
+type EnrichOptions struct {
+	Timezone  bool
+	Elevation bool
+}
+
+// EnrichedPoint geocoded point with optional timezone and elevation details
+type EnrichedPoint struct {
+	Point
+	TimezoneID   string `json:"timezone_id,omitempty"`
+	TimezoneName string `json:"timezone_name,omitempty"`
+	// UTCOffset seconds from UTC including daylight savings
+	UTCOffset int `json:"utc_offset,omitempty"`
+	// Elevation meters above sea level
+	Elevation float64 `json:"elevation,omitempty"`
+}
+
+// GeocodeEnriched geocodes addr then concurrently fetches the enrichments requested in opts
+func (g *geoCodeService) GeocodeEnriched(ctx context.Context, addr *AddressQuery, opts EnrichOptions) (*EnrichedPoint, error) {
+	pt, err := g.GeocodeAddress(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	ep := &EnrichedPoint{Point: *pt}
+	loc := maps.LatLng{Lat: pt.Latitude, Lng: pt.Longitude}
+
+	var wg sync.WaitGroup
+	var tzErr, elErr error
+	if opts.Timezone {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tz, err := g.api(ctx, timezoneAPI).Timezone(ctx, &maps.TimezoneRequest{Location: &loc, Timestamp: g.Clock.Now()})
+			if err != nil {
+				g.log(ctx).Error(ERROR_TIMEZONE, zap.Error(err), statusField(err))
+				tzErr = apiError(err, ErrTimezone)
+				return
+			}
+			ep.TimezoneID = tz.TimeZoneID
+			ep.TimezoneName = tz.TimeZoneName
+			ep.UTCOffset = tz.RawOffset + tz.DstOffset
+		}()
+	}
+	if opts.Elevation {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			els, err := g.api(ctx, elevationAPI).Elevation(ctx, &maps.ElevationRequest{Locations: []maps.LatLng{loc}})
+			if err != nil {
+				g.log(ctx).Error(ERROR_ELEVATION, zap.Error(err), statusField(err))
+				elErr = apiError(err, ErrElevation)
+				return
+			}
+			if len(els) < 1 {
+				g.log(ctx).Error(ERROR_ELEVATION, zap.String("status", STATUS_ZERO_RESULTS))
+				elErr = ErrElevation
+				return
+			}
+			ep.Elevation = els[0].Elevation
+		}()
+	}
+	wg.Wait()
+
+	if tzErr != nil {
+		return nil, tzErr
+	}
+	if elErr != nil {
+		return nil, elErr
+	}
+	return ep, nil
+}
@@ -0,0 +1,53 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodeEnriched(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the lookups run on their own goroutines, their requests are asserted once the call returns
+	var tzReq *maps.TimezoneRequest
+	var elevationReq *maps.ElevationRequest
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(39.74, -104.99, "Denver, CO, USA", "locality")}, nil
+		},
+		timezoneFn: func(r *maps.TimezoneRequest) (*maps.TimezoneResult, error) {
+			tzReq = r
+			return &maps.TimezoneResult{
+				TimeZoneID:   "America/Denver",
+				TimeZoneName: "Mountain Daylight Time",
+				RawOffset:    -25200,
+				DstOffset:    3600,
+			}, nil
+		},
+		elevationFn: func(r *maps.ElevationRequest) ([]maps.ElevationResult, error) {
+			elevationReq = r
+			return []maps.ElevationResult{{Elevation: 1608.6}}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	addr := &AddressQuery{City: "Denver", State: "CO"}
+	ep, err := gsc.GeocodeEnriched(ctx, addr, EnrichOptions{Timezone: true, Elevation: true})
+	require.NoError(t, err)
+	require.Equal(t, "Denver, CO, USA", ep.FormattedAddress)
+	require.Equal(t, "America/Denver", ep.TimezoneID)
+	require.Equal(t, "Mountain Daylight Time", ep.TimezoneName)
+	require.Equal(t, -21600, ep.UTCOffset)
+	require.Equal(t, 1608.6, ep.Elevation)
+	require.Equal(t, 39.74, tzReq.Location.Lat)
+	require.Equal(t, 1, len(elevationReq.Locations))
+
+	ep, err = gsc.GeocodeEnriched(ctx, addr, EnrichOptions{Elevation: true})
+	require.NoError(t, err)
+	require.Equal(t, "", ep.TimezoneID)
+	require.Equal(t, 1608.6, ep.Elevation)
+}
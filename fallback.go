@@ -0,0 +1,113 @@
+package geocode
+
+import (
+	"context"
+	"time"
+)
+
+// fallbackProvider races fallback against primary once primary hasn't answered within after,
+// the first successful answer wins and the other call's context is cancelled
+type fallbackProvider struct {
+	primary  Provider
+	fallback Provider
+	after    time.Duration
+	clock    Clock
+}
+
+func newFallbackProvider(primary, fallback Provider, after time.Duration, clock Clock) fallbackProvider {
+	if after <= 0 {
+		after = DEFAULT_FALLBACK_AFTER
+	}
+	if clock == nil {
+		clock = realClock{}
+	}
+	return fallbackProvider{
+		primary:  primary,
+		fallback: fallback,
+		after:    after,
+		clock:    clock,
+	}
+}
+
+type raceResult struct {
+	v       interface{}
+	err     error
+	primary bool
+}
+
+// race calls primary and, when it's slower than after, fallback too. It returns the first successful
+// result, or primary's error when both fail.
+func (f fallbackProvider) race(ctx context.Context, call func(ctx context.Context, p Provider) (interface{}, error)) (interface{}, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	// cancels the loser
+	defer cancel()
+
+	results := make(chan raceResult, 2)
+	run := func(p Provider, primary bool) {
+		v, err := call(ctx, p)
+		results <- raceResult{v: v, err: err, primary: primary}
+	}
+
+	go run(f.primary, true)
+	select {
+	case r := <-results:
+		return r.v, r.err
+	case <-f.clock.After(f.after):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	go run(f.fallback, false)
+	first := <-results
+	if first.err == nil {
+		return first.v, nil
+	}
+	second := <-results
+	if second.err == nil {
+		return second.v, nil
+	}
+	if first.primary {
+		return nil, first.err
+	}
+	return nil, second.err
+}
+
+func (f fallbackProvider) Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error) {
+	v, err := f.race(ctx, func(ctx context.Context, p Provider) (interface{}, error) {
+		return p.Geocode(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Result), nil
+}
+
+func (f fallbackProvider) ReverseGeocode(ctx context.Context, r *ReverseGeocodeRequest) ([]Result, error) {
+	v, err := f.race(ctx, func(ctx context.Context, p Provider) (interface{}, error) {
+		return p.ReverseGeocode(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]Result), nil
+}
+
+func (f fallbackProvider) Directions(ctx context.Context, r *DirectionsRequest) ([]*Route, error) {
+	v, err := f.race(ctx, func(ctx context.Context, p Provider) (interface{}, error) {
+		return p.Directions(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.([]*Route), nil
+}
+
+func (f fallbackProvider) DistanceMatrix(ctx context.Context, r *DistanceMatrixRequest) (*RouteMatrix, error) {
+	v, err := f.race(ctx, func(ctx context.Context, p Provider) (interface{}, error) {
+		return p.DistanceMatrix(ctx, r)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v.(*RouteMatrix), nil
+}
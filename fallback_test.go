@@ -0,0 +1,96 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+)
+
+// slowProvider answers only once its context is done, recording the cancellation
+type slowProvider struct {
+	fakeProvider
+	cancelled chan error
+}
+
+func (p *slowProvider) Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error) {
+	<-ctx.Done()
+	p.cancelled <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func TestFallbackProvider(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"slow primary, returns fallback's result":      testFallbackWins,
+		"both providers fail, returns primary's error": testFallbackBothFail,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testFallbackWins(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primary := &slowProvider{cancelled: make(chan error, 1)}
+	fallback := &fakeProvider{
+		geocodeFn: func(r *GeocodeRequest) ([]Result, error) {
+			return []Result{{
+				FormattedAddress: "Petaluma, CA 94952, USA",
+				Geometry:         Geometry{Location: LatLng{Lat: 38.24, Lng: -122.64}},
+			}}, nil
+		},
+	}
+
+	gsc, err := NewGeoCodeService(Config{
+		Provider:         primary,
+		FallbackProvider: fallback,
+		FallbackAfter:    100 * time.Millisecond,
+		Clock:            newFakeClock(),
+		AppLogger:        logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA", Country: "US"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+
+	select {
+	case err := <-primary.cancelled:
+		require.Equal(t, context.Canceled, err)
+	case <-time.After(time.Second):
+		t.Fatal("primary provider wasn't cancelled")
+	}
+
+	s := gsc.EffectiveConfig()
+	require.Equal(t, 100*time.Millisecond, s.FallbackAfter)
+	require.NotEmpty(t, s.FallbackProvider)
+}
+
+func testFallbackBothFail(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	primaryErr, fallbackErr := ErrEmptyResponse, ErrOffline
+	release := make(chan struct{})
+	primary := &fakeProvider{
+		geocodeFn: func(r *GeocodeRequest) ([]Result, error) {
+			<-release
+			return nil, primaryErr
+		},
+	}
+	fallback := &fakeProvider{
+		geocodeFn: func(r *GeocodeRequest) ([]Result, error) {
+			close(release)
+			return nil, fallbackErr
+		},
+	}
+
+	p := newFallbackProvider(primary, fallback, time.Millisecond, newFakeClock())
+	_, err := p.Geocode(ctx, &GeocodeRequest{Address: "Petaluma, CA"})
+	require.Equal(t, primaryErr, err)
+}
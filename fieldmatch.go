@@ -0,0 +1,84 @@
+package geocode
+
+import (
+	"context"
+	"strings"
+)
+
+// GeocodeAddressWithFieldMatch geocodes addr and reports which of the requested fields
+// the result matched, postal code fallbacks only match PostalCode and Country
+func (g *geoCodeService) GeocodeAddressWithFieldMatch(ctx context.Context, addr *AddressQuery) (*Point, *GeocodeMeta, error) {
+	pt, meta, r, err := g.geocodeAddress(ctx, addr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// match the defaulted query geocodeAddress sent, on a copy
+	q := *addr
+	if q.Country == "" {
+		q.Country = "USA"
+	}
+	addr = &q
+
+	var comps []Address
+	if r != nil {
+		comps = r.AddressComponents
+	} else {
+		comps = []Address{
+			{LongName: addr.PostalCode, Types: []string{"postal_code"}},
+			{ShortName: countryCode(addr.Country), Types: []string{"country"}},
+		}
+	}
+	meta.FieldMatch = matchFields(addr, comps)
+	return pt, meta, nil
+}
+
+// matchFields compares the non empty fields of addr against address components
+func matchFields(addr *AddressQuery, comps []Address) map[string]bool {
+	has := func(val string, types ...string) bool {
+		for _, c := range comps {
+			for _, t := range c.Types {
+				for _, typ := range types {
+					if t == typ && (strings.EqualFold(c.LongName, val) || strings.EqualFold(c.ShortName, val)) {
+						return true
+					}
+				}
+			}
+		}
+		return false
+	}
+
+	matches := map[string]bool{}
+	if addr.Street != "" {
+		street := strings.ToLower(addr.Street)
+		route := strings.ToLower(componentName(comps, "route"))
+		matches["Street"] = route != "" && strings.Contains(street, route)
+		if num := componentName(comps, "street_number"); matches["Street"] && num != "" {
+			matches["Street"] = strings.Contains(street, strings.ToLower(num))
+		}
+	}
+	if addr.Premise != "" {
+		matches["Premise"] = has(addr.Premise, "premise")
+	}
+	if addr.Subpremise != "" {
+		matches["Subpremise"] = has(addr.Subpremise, "subpremise")
+	}
+	if addr.Unit != "" {
+		matches["Unit"] = has(addr.Unit, "subpremise")
+	}
+	if addr.City != "" {
+		matches["City"] = has(addr.City, "locality", "postal_town", "sublocality")
+	}
+	if addr.State != "" {
+		matches["State"] = has(addr.State, "administrative_area_level_1")
+	}
+	if addr.PostalCode != "" {
+		norm := func(s string) string { return strings.ToUpper(strings.ReplaceAll(s, " ", "")) }
+		pc := norm(componentName(comps, "postal_code"))
+		matches["PostalCode"] = pc != "" && pc == norm(addr.PostalCode)
+	}
+	if addr.Country != "" {
+		matches["Country"] = has(addr.Country, "country") || has(countryCode(addr.Country), "country")
+	}
+	return matches
+}
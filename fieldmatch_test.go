@@ -0,0 +1,49 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodeAddressWithFieldMatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")
+	res.AddressComponents = []maps.AddressComponent{
+		{LongName: "94952", ShortName: "94952", Types: []string{"postal_code"}},
+		{LongName: "Petaluma", ShortName: "Petaluma", Types: []string{"locality", "political"}},
+		{LongName: "California", ShortName: "CA", Types: []string{"administrative_area_level_1", "political"}},
+		{LongName: "United States", ShortName: "US", Types: []string{"country", "political"}},
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	pt, meta, err := gsc.GeocodeAddressWithFieldMatch(ctx, &AddressQuery{PostalCode: "94952", Country: "USA"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, map[string]bool{"PostalCode": true, "Country": true}, meta.FieldMatch)
+	require.False(t, meta.FieldMatch["City"])
+
+	_, meta, err = gsc.GeocodeAddressWithFieldMatch(ctx, &AddressQuery{
+		Street:     "1 Main St",
+		City:       "Sonoma",
+		State:      "CA",
+		PostalCode: "94952",
+	})
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{
+		"Street":     false,
+		"City":       false,
+		"State":      true,
+		"PostalCode": true,
+		"Country":    true,
+	}, meta.FieldMatch)
+}
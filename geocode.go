@@ -3,8 +3,11 @@ package geocode
 import (
 	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
-	"gitlab.com/xerra/common/vincenty"
 	"go.uber.org/zap"
 	"googlemaps.github.io/maps"
 
@@ -14,128 +17,669 @@ import (
 
 type GeoCoder interface {
 	Geocode(ctx context.Context, postalCode, countryCode string) (*Point, error)
+	GeocodePostalDetailed(ctx context.Context, postalCode, countryCode string) (*PostalResult, error)
+	GeocodePostalAll(ctx context.Context, partial, countryCode string) ([]*Point, error)
+	GeocodeAll(ctx context.Context, query string) ([]*Point, error)
+	GeocodeAddressAll(ctx context.Context, addr *AddressQuery) ([]*Point, error)
 	GeocodeAddress(ctx context.Context, addr *AddressQuery) (*Point, error)
+	GeocodeAddresses(ctx context.Context, addrs []*AddressQuery) ([]*Point, []error)
+	GeocodeSimple(addr *AddressQuery) (*Point, error)
+	GeocodeBestEffort(ctx context.Context, addr *AddressQuery) (*Point, Strategy, error)
+	GeocodeWithinPolygon(ctx context.Context, addr *AddressQuery, polygon []*Point) (*Point, error)
+	GeocodeAddressWithMeta(ctx context.Context, addr *AddressQuery) (*Point, *GeocodeMeta, error)
+	GeocodeAddressWithFieldMatch(ctx context.Context, addr *AddressQuery) (*Point, *GeocodeMeta, error)
+	GeocodeEnriched(ctx context.Context, addr *AddressQuery, opts EnrichOptions) (*EnrichedPoint, error)
 	GeocodeLatLong(ctx context.Context, lat, long float64, hint string) (*Point, error)
+	GeocodeLatLongInCountry(ctx context.Context, lat, long float64, country string) (*Point, error)
+	ReverseGeocode(ctx context.Context, p *Point, gr Granularity) (*Point, error)
+	NearestRoadName(ctx context.Context, p *Point) (string, error)
+	ListAdminRegions(ctx context.Context, country string) ([]string, error)
+	FindPlace(ctx context.Context, input string) ([]PlaceCandidate, error)
+	FindOpenPlaces(ctx context.Context, input string, at time.Time) ([]*Place, error)
 	GetDistance(ctx context.Context, u DistanceUnit, source, dest *Point) (float64, error)
+	GetDistanceDual(ctx context.Context, source, dest *Point) (DualDistance, error)
+	GetDistanceWithMethod(ctx context.Context, m DistanceMethod, u DistanceUnit, source, dest *Point) (float64, error)
+	GetDistanceBetweenAddresses(ctx context.Context, u DistanceUnit, a, b *AddressQuery) (float64, error)
+	GetDistances(ctx context.Context, u DistanceUnit, pairs [][2]*Point) ([]float64, error)
+	Centroid(ctx context.Context, addrs []*AddressQuery) (*Point, error)
+	EstimateBatchCost(addrs []*AddressQuery) BatchEstimate
+	WarmCache(ctx context.Context, addrs []*AddressQuery, concurrency int) error
+	WarmCacheAsync(ctx context.Context, addrs []*AddressQuery, concurrency int) *BatchHandle
+	DumpCache() map[string]*Point
+	LastRawResponse() []byte
+	AuditBatch(ctx context.Context, addrs []*AddressQuery) ([]AddressAudit, error)
+	EffectiveConfig() ConfigSummary
+	ClusterByLocality(ctx context.Context, points []*Point) (map[string][]*Point, error)
+	NearestByTravelTime(ctx context.Context, origin *Point, facilities []*Point, mode TravelMode) (*Point, time.Duration, error)
+	SnapToKnown(ctx context.Context, addr *AddressQuery, known []*Point, maxDistance float64, u DistanceUnit) (*Point, error)
 	GetRouteForLatLong(ctx context.Context, origin, destination *Point) ([]*RouteLeg, error)
+	GetRouteForLatLongWithOptions(ctx context.Context, origin, destination *Point, opts *RouteOptions) ([]*RouteLeg, error)
 	GetRouteForAddress(ctx context.Context, origin, destination *AddressQuery) ([]*RouteLeg, error)
+	GetRouteForAddressWithOptions(ctx context.Context, origin, destination *AddressQuery, opts *RouteOptions) ([]*RouteLeg, error)
+	GetRoutePreferredModes(ctx context.Context, origin, destination *Point, modes []TravelMode) ([]*RouteLeg, TravelMode, error)
+	GetRoutesForLatLong(ctx context.Context, origin, destination *Point) ([]*Route, error)
+	GetRoutesForLatLongWithOptions(ctx context.Context, origin, destination *Point, opts *RouteOptions) ([]*Route, error)
+	GetRoutesForAddress(ctx context.Context, origin, destination *AddressQuery) ([]*Route, error)
+	GetRoutesForAddressWithOptions(ctx context.Context, origin, destination *AddressQuery, opts *RouteOptions) ([]*Route, error)
 	GetRouteMatrixForLatLong(ctx context.Context, origins, destinations []*Point) ([]*RouteLeg, error)
 	GetRouteMatrixForAddress(ctx context.Context, origins, destinations []*AddressQuery) ([]*RouteLeg, error)
+	GetRouteMatrixMixed(ctx context.Context, origins, destinations []MatrixInput) ([]*RouteLeg, error)
 }
 
 type Config struct {
+	// GeocoderKey google maps api key, optional with a Provider
 	GeocoderKey string `json:"geocoder_key"`
+	// Provider geocoding and routing backend, defaults to google maps using GeocoderKey
+	Provider Provider `json:"-"`
+	// FallbackProvider raced against the primary provider, Provider or google maps,
+	// when it hasn't answered within FallbackAfter, defaults to DEFAULT_FALLBACK_AFTER
+	FallbackProvider Provider      `json:"-"`
+	FallbackAfter    time.Duration `json:"fallback_after"`
+	// UserAgent sent with maps api requests, defaults to DEFAULT_USER_AGENT
+	UserAgent string `json:"user_agent"`
+	// PreferTypes orders geocoding results by result type, earliest match first, before the top result is selected
+	PreferTypes []string `json:"prefer_types"`
+	// FallbackToPostalCode geocodes the postal code when an address has no results
+	FallbackToPostalCode bool `json:"fallback_to_postal_code"`
+	// SplitStreetUnit moves a unit designator in the street line, e.g. "Unit 4, 123 Main St", into AddressQuery.Unit
+	SplitStreetUnit bool `json:"split_street_unit"`
+	// PreferPostalCodeName makes postal code geocoding select a postal_code result, ahead of PreferTypes,
+	// and return its long name as FormattedAddress
+	PreferPostalCodeName bool `json:"prefer_postal_code_name"`
+	// DistanceCalculator used for distance computations, defaults to vincenty
+	DistanceCalculator DistanceCalculator `json:"-"`
+	// SphereRadiusMeters switches the default calculator to haversine on a sphere of this radius,
+	// vincenty is specific to the earth's ellipsoid so the two can't be combined
+	SphereRadiusMeters float64 `json:"sphere_radius_meters"`
+	// OnResult audit hook called with the request, a *GeocodeRequest or *ReverseGeocodeRequest,
+	// and results of each successful geocoding call
+	OnResult func(op string, request any, results *GeocoderResults) `json:"-"`
+	// StrictPostalValidation validates postal code format before geocoding
+	StrictPostalValidation bool `json:"strict_postal_validation"`
+	// TieBreaker deterministically picks among equally ranked results, Google's order is kept when empty
+	TieBreaker TieBreaker `json:"tie_breaker"`
+	// SelectResult picks the result to geocode to from all of a request's results, in the api's order,
+	// instead of the default selection, returning nil for none
+	SelectResult func(results []Result) (*Result, error) `json:"-"`
+	// RejectPartialMatches fails address geocoding with a *SuggestionsError when the selected result is a partial match
+	RejectPartialMatches bool `json:"reject_partial_matches"`
+	// UseViewportCenter returns the center of the result's viewport instead of its precise location
+	UseViewportCenter bool `json:"use_viewport_center"`
+	// CacheSize max geocoded points kept in the in-memory LRU cache, caching is disabled when 0
+	CacheSize int `json:"cache_size"`
+	// CacheTTL expiry of cached points, defaults to DEFAULT_CACHE_TTL, cached points don't expire when negative
+	CacheTTL time.Duration `json:"cache_ttl"`
+	// RouteCacheTTL expiry of cached routes, up to CacheSize routes are cached separately from points,
+	// defaults to DEFAULT_ROUTE_CACHE_TTL, cached routes don't expire when negative
+	RouteCacheTTL time.Duration `json:"route_cache_ttl"`
+	// StaleAfter age past which cached points, while not expired, are flagged GeocodeMeta.Stale, disabled when 0
+	StaleAfter time.Duration `json:"stale_after"`
+	// OfflineOnly serves geocoding from the cache only, cache misses and uncached methods return ErrOffline
+	OfflineOnly bool `json:"offline_only"`
+	// PricePerCall dollars per geocoding api call used for cost estimates, defaults to DEFAULT_PRICE_PER_CALL
+	PricePerCall float64 `json:"price_per_call"`
+	// AllowSameOriginDestination returns a zero distance route for lat/lng routes whose origin and destination
+	// coincide, instead of ErrSameOriginDestination
+	AllowSameOriginDestination bool `json:"allow_same_origin_destination"`
+	// MaxAddressLength rejects longer address strings before calling the api, defaults to DEFAULT_MAX_ADDRESS_LENGTH
+	MaxAddressLength int `json:"max_address_length"`
+	// AddressFieldOrder order of the fields in address strings sent to the api, e.g. reversed for Japan,
+	// defaults to DEFAULT_ADDRESS_FIELD_ORDER, omitted fields follow in their default order
+	AddressFieldOrder []AddressField `json:"address_field_order"`
+	// MaxRetries times a failed idempotent api call is retried on transient errors, calls aren't retried when 0
+	MaxRetries int `json:"max_retries"`
+	// RetryBackoff wait before the first retry, doubling for each further retry up to MAX_RETRY_BACKOFF
+	// with jitter, defaults to DEFAULT_RETRY_BACKOFF
+	RetryBackoff time.Duration `json:"retry_backoff"`
+	// TitleCaseAddresses title cases all caps or all lower case words of formatted addresses, keeping acronyms
+	// such as USA, NW and state abbreviations upper case
+	TitleCaseAddresses bool `json:"title_case_addresses"`
+	// Clock used for cache expiry and request timestamps, defaults to the system clock
+	Clock Clock `json:"-"`
+	// DedupePlaceIDs collapses results of multi result methods sharing a place id into the most confident one
+	DedupePlaceIDs bool `json:"dedupe_place_ids"`
+	// Language of geocoded formatted addresses, e.g. "de", API default when empty
+	Language string `json:"language"`
+	// Region ccTLD code, e.g. "uk", biasing how ambiguous geocoding queries resolve
+	Region string `json:"region"`
+	// Units of human readable route and route matrix distances, api default for the origin's country when empty
+	Units Units `json:"units"`
+	// Concurrency lookups batch methods keep in flight, defaults to 5
+	Concurrency int `json:"concurrency"`
+	// CaptureRawResponses keeps the raw body of the latest google maps api response for LastRawResponse,
+	// off by default as it buffers every response
+	CaptureRawResponses bool `json:"capture_raw_responses"`
 	logger.AppLogger
 }
 
+// mapsClient is the subset of the google maps client used by the service
+type mapsClient interface {
+	Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error)
+	Directions(ctx context.Context, r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error)
+	DistanceMatrix(ctx context.Context, r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error)
+	Timezone(ctx context.Context, r *maps.TimezoneRequest) (*maps.TimezoneResult, error)
+	Elevation(ctx context.Context, r *maps.ElevationRequest) ([]maps.ElevationResult, error)
+	FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error)
+	PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error)
+}
+
 type geoCodeService struct {
 	Config
-	client *maps.Client
+	// provider geocoding and routing backend, Config.Provider or google, raced against
+	// Config.FallbackProvider when it's set
+	provider   Provider
+	newClient  clientFactory
+	clients    map[apiKind]*lazyClient
+	cache      *pointCache
+	routeCache *pointCache
+	// raw captures google responses when Config.CaptureRawResponses is set
+	raw *rawCapture
 }
 
 func NewGeoCodeService(cfg Config) (*geoCodeService, error) {
-	if cfg.GeocoderKey == "" || cfg.AppLogger == nil {
+	if (cfg.GeocoderKey == "" && cfg.Provider == nil) || cfg.AppLogger == nil {
 		return nil, errors.NewAppError(errors.ERROR_MISSING_REQUIRED)
 	}
 
-	c, err := maps.NewClient(maps.WithAPIKey(cfg.GeocoderKey))
+	var raw *rawCapture
+	if cfg.CaptureRawResponses {
+		raw = &rawCapture{}
+	}
+
+	// the places, timezone and elevation apis aren't part of Provider, they're served by google
+	// when a key is configured and their client is only built once used
+	newClient := func(api apiKind) (mapsClient, error) {
+		if cfg.GeocoderKey == "" {
+			return nil, ErrUnsupportedByProvider
+		}
+		return newMapsClient(cfg, raw)
+	}
+
+	provider := cfg.Provider
+	if provider == nil {
+		// google is the default provider, its client is built upfront to surface option errors
+		// and shared with the other apis
+		c, err := newMapsClient(cfg, raw)
+		if err != nil {
+			cfg.Error("error initializing google maps client")
+			return nil, err
+		}
+		provider = googleProvider{c: c}
+		newClient = func(api apiKind) (mapsClient, error) {
+			return c, nil
+		}
+	}
+	if cfg.FallbackProvider != nil {
+		provider = newFallbackProvider(provider, cfg.FallbackProvider, cfg.FallbackAfter, cfg.Clock)
+	}
+
+	g, err := newGeoCodeServiceWithFactory(cfg, provider, newClient)
 	if err != nil {
-		cfg.Error("error initializing google maps client")
+		cfg.Error("invalid geocoder config", zap.Error(err))
 		return nil, err
 	}
+	g.raw = raw
+	return g, nil
+}
+
+// LastRawResponse returns a copy of the raw body of the most recent google maps api response,
+// nil unless Config.CaptureRawResponses is set. It only holds the latest call, with concurrent calls
+// in flight it's whichever response arrived last.
+func (g *geoCodeService) LastRawResponse() []byte {
+	return g.raw.get()
+}
 
-	gcSrv := geoCodeService{
-		Config: cfg,
-		client: c,
+// newMapsClient returns a google maps client, recording raw responses in raw when it's set
+func newMapsClient(cfg Config, raw *rawCapture) (*maps.Client, error) {
+	var transport http.RoundTripper = newUserAgentTransport(cfg.UserAgent, nil)
+	if raw != nil {
+		transport = newRawCaptureTransport(raw, transport)
 	}
+	return maps.NewClient(
+		maps.WithAPIKey(cfg.GeocoderKey),
+		maps.WithHTTPClient(&http.Client{
+			Transport: &serverErrorTransport{base: transport},
+		}),
+	)
+}
 
-	return &gcSrv, nil
+// newGeoCodeService returns a service using c for every api, through the google provider for geocoding and routing
+func newGeoCodeService(cfg Config, c mapsClient) (*geoCodeService, error) {
+	return newGeoCodeServiceWithFactory(cfg, googleProvider{c: c}, func(api apiKind) (mapsClient, error) {
+		return c, nil
+	})
+}
+
+func newGeoCodeServiceWithFactory(cfg Config, provider Provider, newClient clientFactory) (*geoCodeService, error) {
+	for _, f := range cfg.AddressFieldOrder {
+		if !f.isValid() {
+			return nil, ErrInvalidAddressField
+		}
+	}
+	if !cfg.TieBreaker.isValid() {
+		return nil, ErrInvalidTieBreaker
+	}
+
+	if cfg.DistanceCalculator == nil {
+		if cfg.SphereRadiusMeters > 0 {
+			cfg.DistanceCalculator = haversineCalculator{radius: cfg.SphereRadiusMeters}
+		} else {
+			cfg.DistanceCalculator = vincentyCalculator{}
+		}
+	}
+	if cfg.PricePerCall <= 0 {
+		cfg.PricePerCall = DEFAULT_PRICE_PER_CALL
+	}
+	if cfg.MaxAddressLength <= 0 {
+		cfg.MaxAddressLength = DEFAULT_MAX_ADDRESS_LENGTH
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = realClock{}
+	}
+	if cfg.MaxRetries > 0 && cfg.RetryBackoff <= 0 {
+		cfg.RetryBackoff = DEFAULT_RETRY_BACKOFF
+	}
+	if cfg.CacheSize > 0 && cfg.CacheTTL == 0 {
+		cfg.CacheTTL = DEFAULT_CACHE_TTL
+	}
+	if cfg.CacheSize > 0 && cfg.RouteCacheTTL == 0 {
+		cfg.RouteCacheTTL = DEFAULT_ROUTE_CACHE_TTL
+	}
+
+	if cfg.MaxRetries > 0 {
+		provider = retryProvider{p: provider, policy: retryPolicy{retries: cfg.MaxRetries, backoff: cfg.RetryBackoff, clock: cfg.Clock}}
+	}
+
+	return &geoCodeService{
+		Config:     cfg,
+		provider:   provider,
+		newClient:  newClient,
+		clients:    newLazyClients(),
+		cache:      newPointCache(cfg.CacheSize, cfg.CacheTTL, cfg.Clock),
+		routeCache: newPointCache(cfg.CacheSize, cfg.RouteCacheTTL, cfg.Clock),
+	}, nil
 }
 
 func (g *geoCodeService) Geocode(ctx context.Context, postalCode, countryCode string) (*Point, error) {
+	pt, _, err := g.geocodePostal(ctx, postalCode, countryCode)
+	return pt, err
+}
+
+// geocodePostal geocodes the postal code also returning the selected result
+func (g *geoCodeService) geocodePostal(ctx context.Context, postalCode, countryCode string) (*Point, *Result, error) {
 	if ctx == nil {
-		g.Error("context is nil", zap.Error(ErrNilContext))
-		return nil, ErrNilContext
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, nil, ErrNilContext
 	}
 
 	if countryCode == "" {
 		countryCode = "USA"
 	}
 
-	req := &maps.GeocodingRequest{
-		Components: map[maps.Component]string{
-			maps.ComponentPostalCode: postalCode,
-			maps.ComponentCountry:    countryCode,
+	if g.StrictPostalValidation {
+		if err := ValidatePostalCode(postalCode, countryCode); err != nil {
+			g.log(ctx).Error(ERR_INVALID_POSTAL_CODE, zap.String("postalcode", postalCode), zap.String("country", countryCode))
+			return nil, nil, err
+		}
+	}
+
+	key := fmt.Sprintf("postal|%s|%s", normalizePostalCode(postalCode), normalizeQuery(countryCode))
+	if e, ok := g.cache.get(key); ok {
+		return e.point(), e.result, nil
+	}
+
+	req := &GeocodeRequest{
+		Components: map[string]string{
+			"postal_code": postalCode,
+			"country":     countryCode,
 		},
+		Language: g.Language,
+		Region:   g.Region,
 	}
-	resp, err := g.client.Geocode(ctx, req)
+	resp, err := g.backend().Geocode(ctx, req)
 	if err != nil {
-		g.Error(ERROR_GEOCODING_POSTAL, zap.Error(err))
-		return nil, ErrGeoCodePostalCode
+		g.log(ctx).Error(ERROR_GEOCODING_POSTAL, zap.Error(err), statusField(err))
+		return nil, nil, apiError(err, ErrGeoCodePostalCode)
 	}
+	g.audit("Geocode", req, resp)
+	resp = usableResults(resp)
 
 	if len(resp) < 1 {
-		g.Error(NO_RESULTS)
-		return nil, ErrGeoCodeNoResults
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS))
+		return nil, nil, ErrGeoCodeNoResults
 	}
 
-	r := resp[0]
-	pt := &Point{
-		Latitude:         r.Geometry.Location.Lat,
-		Longitude:        r.Geometry.Location.Lng,
-		FormattedAddress: r.FormattedAddress,
+	r, err := g.selectResult(ctx, resp, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	formatted := r.FormattedAddress
+	if g.PreferPostalCodeName {
+		if name := componentName(r.AddressComponents, "postal_code"); name != "" {
+			formatted = name
+		}
 	}
 
-	return pt, nil
+	pt := g.newPoint(r)
+	pt.FormattedAddress = g.formatted(formatted)
+	g.cache.put(key, pt, false, &r)
+
+	return pt, &r, nil
 }
 
 func (g *geoCodeService) GetRouteForLatLong(ctx context.Context, origin, destination *Point) ([]*RouteLeg, error) {
-	return g.getRoute(ctx, &maps.DirectionsRequest{
+	return g.GetRouteForLatLongWithOptions(ctx, origin, destination, nil)
+}
+
+// GetRouteForLatLongWithOptions GetRouteForLatLong with per request route options
+func (g *geoCodeService) GetRouteForLatLongWithOptions(ctx context.Context, origin, destination *Point, opts *RouteOptions) ([]*RouteLeg, error) {
+	if rt, same, err := g.sameEndpoints(ctx, origin, destination); same {
+		if err != nil {
+			return nil, err
+		}
+		return rt.Legs, nil
+	}
+	return g.getRoute(ctx, &DirectionsRequest{
 		Origin:      fmt.Sprintf("%.6f %.6f", origin.Latitude, origin.Longitude),
 		Destination: fmt.Sprintf("%.6f %.6f", destination.Latitude, destination.Longitude),
-	})
+	}, opts)
+}
+
+// sameEndpoints reports whether origin and destination are within SAME_POINT_TOLERANCE_METERS,
+// returning ErrSameOriginDestination for them or, with AllowSameOriginDestination, a zero distance route
+func (g *geoCodeService) sameEndpoints(ctx context.Context, origin, destination *Point) (*Route, bool, error) {
+	d, err := distance(g.DistanceCalculator, METERS, origin, destination)
+	if err != nil || d > SAME_POINT_TOLERANCE_METERS {
+		// invalid points are left for the api to reject
+		return nil, false, nil
+	}
+	if !g.AllowSameOriginDestination {
+		g.log(ctx).Error(ERR_SAME_ORIGIN_DESTINATION, zap.Float64("meters", d))
+		return nil, true, ErrSameOriginDestination
+	}
+
+	return &Route{
+		Legs: []*RouteLeg{{
+			Start:         origin.FormattedAddress,
+			End:           destination.FormattedAddress,
+			Steps:         []*RouteStep{},
+			Warnings:      []string{},
+			StartLocation: LatLng{Lat: origin.Latitude, Lng: origin.Longitude},
+			EndLocation:   LatLng{Lat: destination.Latitude, Lng: destination.Longitude},
+		}},
+	}, true, nil
 }
 
 func (g *geoCodeService) GetRouteForAddress(ctx context.Context, origin, destination *AddressQuery) ([]*RouteLeg, error) {
-	return g.getRoute(ctx, &maps.DirectionsRequest{
-		Origin:      origin.addressString(),
-		Destination: destination.addressString(),
-	})
+	return g.GetRouteForAddressWithOptions(ctx, origin, destination, nil)
+}
+
+// GetRouteForAddressWithOptions GetRouteForAddress with per request route options
+func (g *geoCodeService) GetRouteForAddressWithOptions(ctx context.Context, origin, destination *AddressQuery, opts *RouteOptions) ([]*RouteLeg, error) {
+	return g.getRoute(ctx, &DirectionsRequest{
+		Origin:      g.addressString(origin),
+		Destination: g.addressString(destination),
+	}, opts)
+}
+
+// GetRoutePreferredModes returns the route for the first of modes, in order, that has one along with that mode,
+// modes defaults to DRIVING and ErrNoRoute is returned when no mode has a route
+func (g *geoCodeService) GetRoutePreferredModes(ctx context.Context, origin, destination *Point, modes []TravelMode) ([]*RouteLeg, TravelMode, error) {
+	if len(modes) < 1 {
+		modes = []TravelMode{DRIVING}
+	}
+	if rt, same, err := g.sameEndpoints(ctx, origin, destination); same {
+		if err != nil {
+			return nil, "", err
+		}
+		mode := modes[0]
+		if mode == "" {
+			mode = DRIVING
+		}
+		return rt.Legs, mode, nil
+	}
+
+	for _, mode := range modes {
+		if _, ok := mode.mapsMode(); !ok {
+			g.log(ctx).Error(ERR_INVALID_TRAVEL_MODE, zap.String("mode", string(mode)))
+			return nil, "", ErrInvalidTravelMode
+		}
+
+		legs, err := g.getRoute(ctx, &DirectionsRequest{
+			Origin:      fmt.Sprintf("%.6f %.6f", origin.Latitude, origin.Longitude),
+			Destination: fmt.Sprintf("%.6f %.6f", destination.Latitude, destination.Longitude),
+			Mode:        mode,
+		}, nil)
+		if err == ErrNoRoute {
+			g.log(ctx).Info("no route, trying next mode", zap.String("mode", string(mode)))
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		if mode == "" {
+			mode = DRIVING
+		}
+		return legs, mode, nil
+	}
+	return nil, "", ErrNoRoute
+}
+
+func (g *geoCodeService) GetRoutesForLatLong(ctx context.Context, origin, destination *Point) ([]*Route, error) {
+	return g.GetRoutesForLatLongWithOptions(ctx, origin, destination, nil)
+}
+
+// GetRoutesForLatLongWithOptions GetRoutesForLatLong with per request route options
+func (g *geoCodeService) GetRoutesForLatLongWithOptions(ctx context.Context, origin, destination *Point, opts *RouteOptions) ([]*Route, error) {
+	if rt, same, err := g.sameEndpoints(ctx, origin, destination); same {
+		if err != nil {
+			return nil, err
+		}
+		return []*Route{rt}, nil
+	}
+	return g.getRoutes(ctx, &DirectionsRequest{
+		Origin:      fmt.Sprintf("%.6f %.6f", origin.Latitude, origin.Longitude),
+		Destination: fmt.Sprintf("%.6f %.6f", destination.Latitude, destination.Longitude),
+	}, opts)
+}
+
+func (g *geoCodeService) GetRoutesForAddress(ctx context.Context, origin, destination *AddressQuery) ([]*Route, error) {
+	return g.GetRoutesForAddressWithOptions(ctx, origin, destination, nil)
+}
+
+// GetRoutesForAddressWithOptions GetRoutesForAddress with per request route options
+func (g *geoCodeService) GetRoutesForAddressWithOptions(ctx context.Context, origin, destination *AddressQuery, opts *RouteOptions) ([]*Route, error) {
+	return g.getRoutes(ctx, &DirectionsRequest{
+		Origin:      g.addressString(origin),
+		Destination: g.addressString(destination),
+	}, opts)
 }
 
-func (g *geoCodeService) getRoute(ctx context.Context, req *maps.DirectionsRequest) ([]*RouteLeg, error) {
-	routes, _, err := g.client.Directions(context.Background(), req)
+// addressString formats a in the configured field order
+func (g *geoCodeService) addressString(a *AddressQuery) string {
+	return a.formatAddress(g.AddressFieldOrder)
+}
+
+func (g *geoCodeService) getRoute(ctx context.Context, req *DirectionsRequest, opts *RouteOptions) ([]*RouteLeg, error) {
+	routes, err := g.getRoutes(ctx, req, opts)
 	if err != nil {
-		g.Error("error getting route", zap.Error(err))
 		return nil, err
 	}
 
 	routeLegs := []*RouteLeg{}
 	for _, rt := range routes {
+		routeLegs = append(routeLegs, rt.Legs...)
+	}
+	return routeLegs, nil
+}
+
+func (g *geoCodeService) getRoutes(ctx context.Context, req *DirectionsRequest, opts *RouteOptions) ([]*Route, error) {
+	u, err := g.routeUnits(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Units = u
+
+	warnings := []string{}
+	if opts != nil {
+		if opts.Mode != "" {
+			if _, ok := opts.Mode.mapsMode(); !ok {
+				g.log(ctx).Error(ERR_INVALID_TRAVEL_MODE, zap.String("mode", string(opts.Mode)))
+				return nil, ErrInvalidTravelMode
+			}
+			req.Mode = opts.Mode
+		}
+		req.Language = opts.Language
+		req.Region = opts.Region
+		if opts.Vehicle != nil {
+			req.Avoid = opts.Vehicle.avoid()
+			if opts.Vehicle.Type == TRUCK {
+				g.log(ctx).Info(WARN_VEHICLE_UNSUPPORTED, zap.String("vehicle", string(opts.Vehicle.Type)))
+				warnings = append(warnings, WARN_VEHICLE_UNSUPPORTED)
+			}
+		}
+		req.Alternatives = opts.MaxAlternatives > 1
+		req.DepartureTime = opts.DepartureTime
+		req.ArrivalTime = opts.ArrivalTime
+	}
+
+	var routes []*Route
+	// traffic aware routes depend on the departure or arrival time and bypass the cache
+	cacheable := req.DepartureTime == "" && req.ArrivalTime == ""
+	key := routeCacheKey(req)
+	var e *cacheEntry
+	ok := false
+	if cacheable {
+		e, ok = g.routeCache.get(key)
+	}
+	if ok {
+		routes = e.routes
+	} else {
+		routes, err = g.backend().Directions(ctx, req)
+		if err != nil {
+			g.log(ctx).Error("error getting route", zap.Error(err), statusField(err))
+			return nil, err
+		}
+		if cacheable {
+			g.routeCache.putRoutes(key, routes)
+		}
+	}
+
+	if len(routes) < 1 {
+		g.log(ctx).Error(ERR_NO_ROUTE, zap.String("status", STATUS_ZERO_RESULTS))
+		return nil, ErrNoRoute
+	}
+
+	if opts != nil && opts.MaxAlternatives > 0 && len(routes) > opts.MaxAlternatives {
+		routes = routes[:opts.MaxAlternatives]
+	}
+
+	var speed float64
+	if opts != nil {
+		speed = opts.SpeedOverride[req.Mode.orDefault()]
+	}
+
+	rts := []*Route{}
+	for _, route := range cloneRoutes(routes) {
+		if len(route.Legs) < 1 {
+			continue
+		}
+		route.Duration = 0
+		for _, leg := range route.Legs {
+			leg.Warnings = append(append([]string{}, warnings...), leg.Warnings...)
+			if speed > 0 {
+				leg.Duration, leg.Estimated = travelTime(leg.Distance, speed), true
+				for _, st := range leg.Steps {
+					st.Duration = travelTime(st.Distance, speed)
+				}
+			}
+			leg.AverageSpeedKmh = averageSpeedKmh(leg.Distance, leg.Duration)
+			route.Duration += leg.Duration
+		}
+		rts = append(rts, route)
+	}
+
+	if len(rts) < 1 {
+		g.log(ctx).Error(ERR_EMPTY_RESPONSE)
+		return nil, ErrEmptyResponse
+	}
+	return rts, nil
+}
+
+// routeCacheKey identifies a directions request by its endpoints, mode and options
+func routeCacheKey(req *DirectionsRequest) string {
+	avoid := append([]string{}, req.Avoid...)
+	sort.Strings(avoid)
+	return fmt.Sprintf("route|%s|%s|%s|%s|%s|%s|%s|%t",
+		normalizeQuery(req.Origin), normalizeQuery(req.Destination), req.Mode.orDefault(),
+		req.Language, req.Region, req.Units, strings.Join(avoid, ","), req.Alternatives)
+}
+
+// routeUnits validates Config.Units, directions and matrix requests both use it
+func (g *geoCodeService) routeUnits(ctx context.Context) (Units, error) {
+	if _, ok := g.Units.mapsUnits(); !ok {
+		g.log(ctx).Error(ERR_INVALID_UNITS, zap.String("units", string(g.Units)))
+		return "", ErrInvalidUnits
+	}
+	return g.Units, nil
+}
+
+// cloneRoutes deep copies routes, cached and provider routes are left untouched by the post processing
+func cloneRoutes(routes []*Route) []*Route {
+	rts := make([]*Route, 0, len(routes))
+	for _, rt := range routes {
+		if rt == nil {
+			continue
+		}
+		route := *rt
+		route.Legs = make([]*RouteLeg, 0, len(rt.Legs))
 		for _, l := range rt.Legs {
-			routeLegs = append(routeLegs, &RouteLeg{
-				Start:    l.StartAddress,
-				End:      l.EndAddress,
-				Duration: l.Duration,
-				Distance: l.Distance.Meters,
-			})
+			if l == nil {
+				continue
+			}
+			leg := *l
+			leg.Warnings = append([]string{}, l.Warnings...)
+			leg.Steps = make([]*RouteStep, 0, len(l.Steps))
+			for _, st := range l.Steps {
+				if st == nil {
+					continue
+				}
+				step := *st
+				leg.Steps = append(leg.Steps, &step)
+			}
+			route.Legs = append(route.Legs, &leg)
 		}
+		rts = append(rts, &route)
 	}
-	return routeLegs, nil
+	return rts
+}
+
+// travelTime to cover meters at kmh, to the second like the api's durations
+func travelTime(meters int, kmh float64) time.Duration {
+	secs := float64(meters) * 3600 / (kmh * 1000)
+	return time.Duration(secs * float64(time.Second)).Round(time.Second)
+}
+
+// averageSpeedKmh implied average speed covering meters in d, zero without a duration
+func averageSpeedKmh(meters int, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(meters) / 1000 / d.Hours()
 }
 
 func (g *geoCodeService) GetRouteMatrixForAddress(ctx context.Context, origins, destinations []*AddressQuery) ([]*RouteLeg, error) {
 	originStrs := []string{}
 	for _, v := range origins {
-		originStrs = append(originStrs, v.addressString())
+		originStrs = append(originStrs, g.addressString(v))
 	}
 
 	destStrs := []string{}
 	for _, v := range destinations {
-		destStrs = append(destStrs, v.addressString())
+		destStrs = append(destStrs, g.addressString(v))
 	}
 
-	return g.getRouteMatrix(ctx, &maps.DistanceMatrixRequest{
+	return g.getRouteMatrix(ctx, &DistanceMatrixRequest{
 		Origins:      originStrs,
 		Destinations: destStrs,
 	})
@@ -152,123 +696,683 @@ func (g *geoCodeService) GetRouteMatrixForLatLong(ctx context.Context, origins,
 		destStrs = append(destStrs, fmt.Sprintf("%.6f %.6f", v.Latitude, v.Longitude))
 	}
 
-	return g.getRouteMatrix(ctx, &maps.DistanceMatrixRequest{
+	return g.getRouteMatrix(ctx, &DistanceMatrixRequest{
 		Origins:      originStrs,
 		Destinations: destStrs,
 	})
 }
 
-func (g *geoCodeService) getRouteMatrix(ctx context.Context, req *maps.DistanceMatrixRequest) ([]*RouteLeg, error) {
-	resp, err := g.client.DistanceMatrix(ctx, req)
+// GetRouteMatrixMixed returns the route matrix for origins and destinations given as points or addresses
+func (g *geoCodeService) GetRouteMatrixMixed(ctx context.Context, origins, destinations []MatrixInput) ([]*RouteLeg, error) {
+	originStrs, err := g.matrixStrings(ctx, origins)
+	if err != nil {
+		return nil, err
+	}
+
+	destStrs, err := g.matrixStrings(ctx, destinations)
+	if err != nil {
+		return nil, err
+	}
+
+	return g.getRouteMatrix(ctx, &DistanceMatrixRequest{
+		Origins:      originStrs,
+		Destinations: destStrs,
+	})
+}
+
+func (g *geoCodeService) matrixStrings(ctx context.Context, inputs []MatrixInput) ([]string, error) {
+	strs := []string{}
+	for i, v := range inputs {
+		switch {
+		case v.Point != nil && v.Address == nil:
+			strs = append(strs, fmt.Sprintf("%.6f %.6f", v.Point.Latitude, v.Point.Longitude))
+		case v.Address != nil && v.Point == nil:
+			strs = append(strs, g.addressString(v.Address))
+		default:
+			g.log(ctx).Error(ERR_INVALID_MATRIX_INPUT, zap.Int("index", i))
+			return nil, ErrInvalidMatrixInput
+		}
+	}
+	return strs, nil
+}
+
+func (g *geoCodeService) getRouteMatrix(ctx context.Context, req *DistanceMatrixRequest) ([]*RouteLeg, error) {
+	u, err := g.routeUnits(ctx)
 	if err != nil {
-		g.Error("error getting route matrix", zap.Error(err))
 		return nil, err
 	}
+	req.Units = u
 
+	resp, err := g.backend().DistanceMatrix(ctx, req)
+	if err != nil {
+		g.log(ctx).Error("error getting route matrix", zap.Error(err), statusField(err))
+		return nil, err
+	}
+
+	if resp == nil || len(resp.Rows) < 1 ||
+		len(resp.Rows) != len(resp.OriginAddresses) {
+		g.log(ctx).Error(ERR_EMPTY_RESPONSE)
+		return nil, ErrEmptyResponse
+	}
+
+	found, status := false, ""
 	routeLegs := []*RouteLeg{}
 	for i, row := range resp.Rows {
-		for j, elem := range row.Elements {
+		for j, elem := range row {
+			if j >= len(resp.DestinationAddresses) {
+				continue
+			}
+			if elem.Status != STATUS_OK {
+				status = elem.Status
+				continue
+			}
+			found = true
 			if resp.OriginAddresses[i] != resp.DestinationAddresses[j] {
 				routeLegs = append(routeLegs, &RouteLeg{
-					Start:    resp.OriginAddresses[i],
-					End:      resp.DestinationAddresses[j],
-					Duration: elem.Duration,
-					Distance: elem.Distance.Meters,
+					Start:           resp.OriginAddresses[i],
+					End:             resp.DestinationAddresses[j],
+					Duration:        elem.Duration,
+					Distance:        elem.Distance,
+					DistanceText:    elem.DistanceText,
+					OriginIndex:     i,
+					DestIndex:       j,
+					AverageSpeedKmh: averageSpeedKmh(elem.Distance, elem.Duration),
 				})
 			}
 		}
 	}
 
+	if !found {
+		g.log(ctx).Error(ERR_NO_ROUTE, zap.String("status", status))
+		return nil, ErrNoRoute
+	}
+
 	return routeLegs, nil
 }
 
 func (g *geoCodeService) GeocodeAddress(ctx context.Context, addr *AddressQuery) (*Point, error) {
+	pt, _, err := g.GeocodeAddressWithMeta(ctx, addr)
+	return pt, err
+}
+
+func (g *geoCodeService) GeocodeAddressWithMeta(ctx context.Context, addr *AddressQuery) (*Point, *GeocodeMeta, error) {
+	pt, meta, _, err := g.geocodeAddress(ctx, addr)
+	return pt, meta, err
+}
+
+// geocodeAddress geocodes addr also returning the selected result, which is nil for postal code fallbacks
+func (g *geoCodeService) geocodeAddress(ctx context.Context, addr *AddressQuery) (*Point, *GeocodeMeta, *Result, error) {
 	if ctx == nil {
-		g.Error("context is nil", zap.Error(ErrNilContext))
-		return nil, ErrNilContext
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, nil, nil, ErrNilContext
 	}
 
+	// default and split a copy, the caller's query is left as given
+	q := *addr
+	addr = &q
 	if addr.Country == "" {
 		addr.Country = "USA"
 	}
+	if g.SplitStreetUnit {
+		addr.splitUnit()
+	}
+
+	addrStr := g.addressString(addr)
+	if len(addrStr) > g.MaxAddressLength {
+		g.log(ctx).Error(ERR_ADDRESS_TOO_LONG, zap.Int("length", len(addrStr)), zap.Int("limit", g.MaxAddressLength))
+		return nil, nil, nil, ErrAddressTooLong
+	}
 
-	req := &maps.GeocodingRequest{
-		Address: addr.addressString(),
+	key := "address|" + normalizeQuery(addrStr)
+	if e, ok := g.cache.get(key); ok {
+		return e.point(), &GeocodeMeta{Degraded: e.degraded, Stale: g.isStale(e)}, e.result, nil
 	}
 
-	resp, err := g.client.Geocode(ctx, req)
+	req := &GeocodeRequest{
+		Address:  addrStr,
+		Language: g.Language,
+		Region:   g.Region,
+	}
+	if addr.Premise != "" || addr.Subpremise != "" {
+		// there's no premise component filter, restrict the search area instead
+		// so the premise resolves within the given postal code and country
+		req.Components = map[string]string{
+			"country": addr.Country,
+		}
+		if addr.PostalCode != "" {
+			req.Components["postal_code"] = addr.PostalCode
+		}
+	}
+
+	resp, err := g.backend().Geocode(ctx, req)
 	if err != nil {
-		g.Error(ERROR_GEOCODING_ADDRESS, zap.Error(err))
-		return nil, ErrGeoCodeAddress
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return nil, nil, nil, apiError(err, ErrGeoCodeAddress)
 	}
+	g.audit("GeocodeAddress", req, resp)
+	resp = usableResults(resp)
 
 	if len(resp) < 1 {
-		g.Error(NO_RESULTS)
-		return nil, ErrGeoCodeNoResults
+		if g.FallbackToPostalCode && addr.PostalCode != "" {
+			g.log(ctx).Info("no address results, falling back to postal code", zap.String("postalcode", addr.PostalCode))
+			pt, err := g.Geocode(ctx, addr.PostalCode, addr.Country)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			g.cache.put(key, pt, true, nil)
+			return pt, &GeocodeMeta{Degraded: true}, nil, nil
+		}
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS))
+		return nil, nil, nil, ErrGeoCodeNoResults
 	}
 
-	r := resp[0]
-	pt := &Point{
-		Latitude:         r.Geometry.Location.Lat,
-		Longitude:        r.Geometry.Location.Lng,
-		FormattedAddress: r.FormattedAddress,
+	r, err := g.selectResult(ctx, resp, false)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if g.RejectPartialMatches && r.PartialMatch {
+		g.log(ctx).Error(NO_RESULTS, zap.String("reason", "partial match"), zap.Int("results", len(resp)))
+		return nil, nil, nil, newSuggestionsError(ErrGeoCodeNoResults, resp)
 	}
 
-	return pt, nil
+	pt := g.newPoint(r)
+	g.cache.put(key, pt, false, &r)
+
+	return pt, &GeocodeMeta{}, &r, nil
+}
+
+// GeocodeAll returns a point for every result of the free form query, in the api's order
+func (g *geoCodeService) GeocodeAll(ctx context.Context, query string) ([]*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return nil, ErrInvalidAddress
+	}
+	return g.geocodeAll(ctx, query)
+}
+
+// GeocodeAddressAll returns a point for every result of addr, in the api's order
+func (g *geoCodeService) GeocodeAddressAll(ctx context.Context, addr *AddressQuery) ([]*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+	if addr == nil {
+		return nil, ErrInvalidAddress
+	}
+	// default a copy, the caller's query is left as given
+	q := *addr
+	if q.Country == "" {
+		q.Country = "USA"
+	}
+	return g.geocodeAll(ctx, g.addressString(&q))
+}
+
+func (g *geoCodeService) geocodeAll(ctx context.Context, addrStr string) ([]*Point, error) {
+	if len(addrStr) > g.MaxAddressLength {
+		g.log(ctx).Error(ERR_ADDRESS_TOO_LONG, zap.Int("length", len(addrStr)), zap.Int("limit", g.MaxAddressLength))
+		return nil, ErrAddressTooLong
+	}
+
+	req := &GeocodeRequest{
+		Address:  addrStr,
+		Language: g.Language,
+		Region:   g.Region,
+	}
+	resp, err := g.backend().Geocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("GeocodeAll", req, resp)
+	resp = usableResults(resp)
+	if g.DedupePlaceIDs {
+		resp = dedupeByPlaceID(resp)
+	}
+	if len(resp) < 1 {
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS))
+		return nil, ErrGeoCodeNoResults
+	}
+
+	pts := []*Point{}
+	for _, r := range resp {
+		pts = append(pts, g.newPoint(r))
+	}
+	return pts, nil
 }
 
 func (g *geoCodeService) GeocodeLatLong(ctx context.Context, lat, long float64, hint string) (*Point, error) {
+	return g.geocodeLatLong(ctx, lat, long, "")
+}
+
+// GeocodeLatLongInCountry reverse geocodes to a result in country, for coordinates near a border,
+// ErrGeoCodeNoResults is returned when none of the results are in country
+func (g *geoCodeService) GeocodeLatLongInCountry(ctx context.Context, lat, long float64, country string) (*Point, error) {
+	if strings.TrimSpace(country) == "" {
+		return nil, ErrMissingCountry
+	}
+	return g.geocodeLatLong(ctx, lat, long, countryCode(country))
+}
+
+// geocodeLatLong reverse geocodes to the selected result, restricted to results in the alpha-2 country when set
+func (g *geoCodeService) geocodeLatLong(ctx context.Context, lat, long float64, country string) (*Point, error) {
 	if ctx == nil {
-		g.Error("context is nil", zap.Error(ErrNilContext))
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
 		return nil, ErrNilContext
 	}
+	if !validLatLng(lat, long) {
+		g.log(ctx).Error(ERR_INVALID_LAT_LNG, zap.Float64("lat", lat), zap.Float64("long", long))
+		return nil, ErrInvalidGeoLatLng
+	}
+
+	key := "latlng|" + coordKey(lat, long, cacheKeyPrecision)
+	if country != "" {
+		key += "|" + country
+	}
+	if e, ok := g.cache.get(key); ok {
+		return e.point(), nil
+	}
 
-	req := &maps.GeocodingRequest{
-		LatLng: &maps.LatLng{
+	req := &ReverseGeocodeRequest{
+		LatLng: LatLng{
 			Lat: lat,
 			Lng: long,
 		},
+		Language: g.Language,
 	}
-	resp, err := g.client.Geocode(ctx, req)
+	resp, err := g.backend().ReverseGeocode(ctx, req)
 	if err != nil {
-		g.Error(ERROR_GEOCODING_ADDRESS, zap.Error(err))
-		return nil, ErrGeoCodeAddress
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("GeocodeLatLong", req, resp)
+	resp = usableResults(resp)
+	if country != "" {
+		resp = inCountry(resp, country)
 	}
 
 	if len(resp) < 1 {
-		g.Error(NO_RESULTS)
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS), zap.String("country", country))
 		return nil, ErrGeoCodeNoResults
 	}
 
-	r := resp[0]
-	pt := &Point{
-		Latitude:         r.Geometry.Location.Lat,
-		Longitude:        r.Geometry.Location.Lng,
-		FormattedAddress: r.FormattedAddress,
+	r, err := g.selectResult(ctx, resp, false)
+	if err != nil {
+		return nil, err
 	}
+	pt := g.newPoint(r)
+	g.cache.put(key, pt, false, &r)
 
 	return pt, nil
 }
 
+// NearestRoadName reverse geocodes p to the nearest road and returns its name
+func (g *geoCodeService) NearestRoadName(ctx context.Context, p *Point) (string, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return "", ErrNilContext
+	}
+
+	if p == nil || !p.IsValid() {
+		return "", ErrInvalidGeoLatLng
+	}
+
+	req := &ReverseGeocodeRequest{
+		LatLng: LatLng{
+			Lat: p.Latitude,
+			Lng: p.Longitude,
+		},
+		ResultTypes: []string{"route"},
+		Language:    g.Language,
+	}
+	resp, err := g.backend().ReverseGeocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return "", apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("NearestRoadName", req, resp)
+
+	for _, r := range resp {
+		if name := componentName(r.AddressComponents, "route"); name != "" {
+			return name, nil
+		}
+	}
+
+	g.log(ctx).Error(ERR_NO_ROAD, zap.String("status", STATUS_ZERO_RESULTS))
+	return "", ErrNoRoad
+}
+
 func (g *geoCodeService) GetDistance(ctx context.Context, u DistanceUnit, source, dest *Point) (float64, error) {
-	if source == nil || dest == nil || !source.IsValid() || !dest.IsValid() {
-		return 0, ErrInvalidGeoLatLng
-	}
-
-	origin := vincenty.LatLng{Latitude: source.Latitude, Longitude: source.Longitude}
-	end := vincenty.LatLng{Latitude: dest.Latitude, Longitude: dest.Longitude}
-	d := vincenty.Inverse(origin, end)
-
-	switch u {
-	case KM:
-		return d.Kilometers(), nil
-	case MILES:
-		return d.Miles(), nil
-	case METERS:
-		return d.Meters(), nil
-	case FEET:
-		return d.Feet(), nil
+	return distance(g.DistanceCalculator, u, source, dest)
+}
+
+// GetDistanceWithMethod computes the distance with the given method instead of the configured calculator,
+// haversine uses SphereRadiusMeters when set, the mean earth radius otherwise
+func (g *geoCodeService) GetDistanceWithMethod(ctx context.Context, m DistanceMethod, u DistanceUnit, source, dest *Point) (float64, error) {
+	switch m {
+	case VINCENTY:
+		return distance(vincentyCalculator{}, u, source, dest)
+	case HAVERSINE:
+		radius := g.SphereRadiusMeters
+		if radius <= 0 {
+			radius = EARTH_RADIUS_METERS
+		}
+		return distance(haversineCalculator{radius: radius}, u, source, dest)
 	default:
+		return 0, ErrInvalidDistanceMethod
+	}
+}
+
+// GetDistanceDual computes the distance once, returning it in metric and imperial units
+func (g *geoCodeService) GetDistanceDual(ctx context.Context, source, dest *Point) (DualDistance, error) {
+	m, err := distance(g.DistanceCalculator, METERS, source, dest)
+	if err != nil {
+		return DualDistance{}, err
+	}
+	d := DualDistance{Meters: m}
+	d.Kilometers, _ = fromMeters(m, KM)
+	d.Miles, _ = fromMeters(m, MILES)
+	d.Feet, _ = fromMeters(m, FEET)
+	return d, nil
+}
+
+// GetDistances computes the distance for each source/destination pair
+func (g *geoCodeService) GetDistances(ctx context.Context, u DistanceUnit, pairs [][2]*Point) ([]float64, error) {
+	if !u.isValid() {
+		return nil, ErrInvalidGeoUnit
+	}
+
+	ds := make([]float64, len(pairs))
+	for i, pair := range pairs {
+		d, err := distance(g.DistanceCalculator, u, pair[0], pair[1])
+		if err != nil {
+			g.log(ctx).Error(ERR_INVALID_LAT_LNG, zap.Int("pair", i))
+			return nil, err
+		}
+		ds[i] = d
+	}
+	return ds, nil
+}
+
+// GetDistanceBetweenAddresses geocodes a and b, once when they normalize to the same address,
+// and returns the straight line distance between them
+func (g *geoCodeService) GetDistanceBetweenAddresses(ctx context.Context, u DistanceUnit, a, b *AddressQuery) (float64, error) {
+	if !u.isValid() {
 		return 0, ErrInvalidGeoUnit
 	}
+	if a == nil || b == nil {
+		return 0, ErrInvalidAddress
+	}
+
+	// compare and geocode copies, the caller's queries are left as given
+	ca, cb := *a, *b
+	for _, q := range []*AddressQuery{&ca, &cb} {
+		if q.Country == "" {
+			q.Country = "USA"
+		}
+	}
+
+	pa, err := g.GeocodeAddress(ctx, &ca)
+	if err != nil {
+		g.log(ctx).Error("error geocoding first address", zap.Error(err))
+		return 0, err
+	}
+	if normalizeQuery(g.addressString(&ca)) == normalizeQuery(g.addressString(&cb)) {
+		return 0, nil
+	}
+	pb, err := g.GeocodeAddress(ctx, &cb)
+	if err != nil {
+		g.log(ctx).Error("error geocoding second address", zap.Error(err))
+		return 0, err
+	}
+	return distance(g.DistanceCalculator, u, pa, pb)
+}
+
+// audit hands a successful geocoding response to the configured OnResult hook
+func (g *geoCodeService) audit(op string, req interface{}, resp []Result) {
+	if g.OnResult == nil {
+		return
+	}
+	results := &GeocoderResults{
+		Results: append([]Result{}, resp...),
+		Status:  STATUS_OK,
+	}
+	if len(resp) < 1 {
+		results.Status = STATUS_ZERO_RESULTS
+	}
+	g.OnResult(op, req, results)
+}
+
+// formatted post processes a formatted address per the config
+func (g *geoCodeService) formatted(addr string) string {
+	if g.TitleCaseAddresses {
+		return titleCase(addr)
+	}
+	return addr
+}
+
+// newPoint returns the point of a geocoding result
+func (g *geoCodeService) newPoint(r Result) *Point {
+	loc := g.location(r)
+	return &Point{
+		Latitude:         loc.Lat,
+		Longitude:        loc.Lng,
+		FormattedAddress: g.formatted(r.FormattedAddress),
+		PlaceID:          r.PlaceId,
+		Types:            append([]string(nil), r.Types...),
+	}
+}
+
+// location returns the result's coordinate, or its viewport center with UseViewportCenter
+func (g *geoCodeService) location(r Result) LatLng {
+	vp := r.Geometry.Viewport
+	if !g.UseViewportCenter || vp.Northeast == vp.Southwest {
+		return r.Geometry.Location
+	}
+
+	ne, sw := vp.Northeast, vp.Southwest
+	if sw.Lng > ne.Lng {
+		// viewport crosses the antimeridian
+		ne.Lng += 360
+	}
+	lng := (ne.Lng + sw.Lng) / 2
+	if lng > 180 {
+		lng -= 360
+	}
+	return LatLng{Lat: (ne.Lat + sw.Lat) / 2, Lng: lng}
+}
+
+// usableResults drops results without a location, rarely returned by the api,
+// which would otherwise resolve to (0,0)
+func usableResults(resp []Result) []Result {
+	usable := make([]Result, 0, len(resp))
+	for _, r := range resp {
+		if r.Geometry.Location != (LatLng{}) {
+			usable = append(usable, r)
+		}
+	}
+	return usable
+}
+
+// selectResult picks the result to geocode to. The SelectResult callback, when set, decides alone,
+// otherwise results are ordered by type, postal_code first for postal lookups with PreferPostalCodeName
+// then PreferTypes, and the TieBreaker picks among the top ranked ones
+func (g *geoCodeService) selectResult(ctx context.Context, resp []Result, postal bool) (Result, error) {
+	if g.SelectResult != nil {
+		// the callback gets a copy, it can't reorder or modify the results
+		r, err := g.SelectResult(append([]Result{}, resp...))
+		if err != nil {
+			g.log(ctx).Error("error selecting result", zap.Error(err))
+			return Result{}, err
+		}
+		if r == nil {
+			g.log(ctx).Error(NO_RESULTS, zap.String("reason", "no result selected"), zap.Int("results", len(resp)))
+			return Result{}, ErrGeoCodeNoResults
+		}
+		return *r, nil
+	}
+
+	types := g.PreferTypes
+	if postal && g.PreferPostalCodeName {
+		types = append([]string{"postal_code"}, g.PreferTypes...)
+	}
+	if len(types) > 0 {
+		resp = orderByTypes(resp, types)
+	}
+	if g.TieBreaker != "" {
+		return breakTie(resp, types, g.TieBreaker), nil
+	}
+	return resp[0], nil
+}
+
+// breakTie picks among the results tied with the top result, those with the same preferred type rank,
+// location type and partial match flag, using the tie breaker
+func breakTie(ordered []Result, types []string, tb TieBreaker) Result {
+	top := ordered[0]
+	best := top
+	for _, r := range ordered[1:] {
+		if typeRank(r, types) != typeRank(top, types) ||
+			r.Geometry.LocationType != top.Geometry.LocationType ||
+			r.PartialMatch != top.PartialMatch {
+			continue
+		}
+		switch tb {
+		case TIE_BREAK_PLACE_ID:
+			if r.PlaceId < best.PlaceId {
+				best = r
+			}
+		case TIE_BREAK_NORTHWEST:
+			rl, bl := r.Geometry.Location, best.Geometry.Location
+			if rl.Lat > bl.Lat || (rl.Lat == bl.Lat && rl.Lng < bl.Lng) {
+				best = r
+			}
+		}
+	}
+	return best
+}
+
+// typeRank index of the earliest preferred type the result has, len(types) when none
+func typeRank(r Result, types []string) int {
+	for i, pt := range types {
+		for _, t := range r.Types {
+			if t == pt {
+				return i
+			}
+		}
+	}
+	return len(types)
+}
+
+// locationTypeRank orders geocoding location types from most to least precise
+var locationTypeRank = map[string]int{
+	string(maps.GeocodeAccuracyRooftop):           0,
+	string(maps.GeocodeAccuracyRangeInterpolated): 1,
+	string(maps.GeocodeAccuracyGeometricCenter):   2,
+	string(maps.GeocodeAccuracyApproximate):       3,
+}
+
+// confidence ranks r by location type precision, then full over partial matches, lower is better
+func confidence(r Result) int {
+	rank, ok := locationTypeRank[r.Geometry.LocationType]
+	if !ok {
+		rank = len(locationTypeRank)
+	}
+	rank *= 2
+	if r.PartialMatch {
+		rank++
+	}
+	return rank
+}
+
+// dedupeByPlaceID collapses results sharing a place id into the most confident one,
+// kept at the position of the place's first result, results without a place id are kept
+func dedupeByPlaceID(results []Result) []Result {
+	deduped := []Result{}
+	seen := map[string]int{}
+	for _, r := range results {
+		if r.PlaceId == "" {
+			deduped = append(deduped, r)
+			continue
+		}
+		i, ok := seen[r.PlaceId]
+		if !ok {
+			seen[r.PlaceId] = len(deduped)
+			deduped = append(deduped, r)
+			continue
+		}
+		if confidence(r) < confidence(deduped[i]) {
+			deduped[i] = r
+		}
+	}
+	return deduped
+}
+
+// orderByTypes stable sorts results by their earliest matching preferred type,
+// results matching none of the types keep their order at the end
+func orderByTypes(results []Result, types []string) []Result {
+	ordered := make([]Result, len(results))
+	copy(ordered, results)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return typeRank(ordered[i], types) < typeRank(ordered[j], types)
+	})
+	return ordered
+}
+
+// statusField returns google's response status, e.g. OVER_QUERY_LIMIT, as a log field
+func statusField(err error) zap.Field {
+	return zap.String("status", responseStatus(err))
+}
+
+// responseStatus google's response status of a maps api error, empty for other errors
+func responseStatus(err error) string {
+	if err == nil {
+		return ""
+	}
+	msg := err.Error()
+	if !strings.HasPrefix(msg, "maps: ") {
+		return ""
+	}
+	status := strings.TrimPrefix(msg, "maps: ")
+	if i := strings.Index(status, " - "); i >= 0 {
+		status = status[:i]
+	}
+	return status
+}
+
+// inCountry keeps the results whose country component is the alpha-2 country
+func inCountry(results []Result, country string) []Result {
+	kept := []Result{}
+	for _, r := range results {
+		if strings.EqualFold(componentShortName(r.AddressComponents, "country"), country) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// componentShortName returns the short name of the first address component of given type
+func componentShortName(comps []Address, typ string) string {
+	for _, c := range comps {
+		for _, t := range c.Types {
+			if t == typ {
+				return c.ShortName
+			}
+		}
+	}
+	return ""
+}
+
+// componentName returns the long name of the first address component of given type
+func componentName(comps []Address, typ string) string {
+	for _, c := range comps {
+		for _, t := range c.Types {
+			if t == typ {
+				return c.LongName
+			}
+		}
+	}
+	return ""
 }
@@ -0,0 +1,1308 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"googlemaps.github.io/maps"
+)
+
+type fakeMapsClient struct {
+	mu           sync.Mutex
+	geocodeFn    func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error)
+	directionsFn func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error)
+	matrixFn     func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error)
+	timezoneFn   func(r *maps.TimezoneRequest) (*maps.TimezoneResult, error)
+	elevationFn  func(r *maps.ElevationRequest) ([]maps.ElevationResult, error)
+	findPlaceFn  func(r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error)
+	detailsFn    func(r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error)
+	geocodeReqs  []*maps.GeocodingRequest
+	routeReqs    []*maps.DirectionsRequest
+	matrixReqs   []*maps.DistanceMatrixRequest
+}
+
+func (f *fakeMapsClient) Geocode(ctx context.Context, r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+	f.mu.Lock()
+	f.geocodeReqs = append(f.geocodeReqs, r)
+	f.mu.Unlock()
+	if f.geocodeFn == nil {
+		return nil, nil
+	}
+	return f.geocodeFn(r)
+}
+
+func (f *fakeMapsClient) Directions(ctx context.Context, r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+	f.mu.Lock()
+	f.routeReqs = append(f.routeReqs, r)
+	f.mu.Unlock()
+	if err := ctx.Err(); err != nil {
+		// like the maps client, fail fast once the context is done
+		return nil, nil, err
+	}
+	if f.directionsFn == nil {
+		return nil, nil, nil
+	}
+	return f.directionsFn(r)
+}
+
+func (f *fakeMapsClient) DistanceMatrix(ctx context.Context, r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+	f.mu.Lock()
+	f.matrixReqs = append(f.matrixReqs, r)
+	f.mu.Unlock()
+	if f.matrixFn == nil {
+		return nil, nil
+	}
+	return f.matrixFn(r)
+}
+
+func (f *fakeMapsClient) Timezone(ctx context.Context, r *maps.TimezoneRequest) (*maps.TimezoneResult, error) {
+	if f.timezoneFn == nil {
+		return &maps.TimezoneResult{}, nil
+	}
+	return f.timezoneFn(r)
+}
+
+func (f *fakeMapsClient) Elevation(ctx context.Context, r *maps.ElevationRequest) ([]maps.ElevationResult, error) {
+	if f.elevationFn == nil {
+		return nil, nil
+	}
+	return f.elevationFn(r)
+}
+
+func (f *fakeMapsClient) FindPlaceFromText(ctx context.Context, r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+	if f.findPlaceFn == nil {
+		return maps.FindPlaceFromTextResponse{}, nil
+	}
+	return f.findPlaceFn(r)
+}
+
+func (f *fakeMapsClient) PlaceDetails(ctx context.Context, r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+	if f.detailsFn == nil {
+		return maps.PlaceDetailsResult{}, nil
+	}
+	return f.detailsFn(r)
+}
+
+func (f *fakeMapsClient) geocodeCalls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.geocodeReqs)
+}
+
+func newFakeService(t *testing.T, cfg Config, c *fakeMapsClient) *geoCodeService {
+	t.Helper()
+
+	if cfg.AppLogger == nil {
+		cfg.AppLogger = logger.NewTestAppLogger(t.TempDir())
+	}
+	gsc, err := newGeoCodeService(cfg, c)
+	require.NoError(t, err)
+	return gsc
+}
+
+type logEntry struct {
+	msg    string
+	fields []zap.Field
+}
+
+type captureLogger struct {
+	logger.AppLogger
+	mu      sync.Mutex
+	entries []logEntry
+}
+
+func (l *captureLogger) Info(msg string, fields ...zap.Field) {
+	l.capture(msg, fields)
+}
+
+func (l *captureLogger) Error(msg string, fields ...zap.Field) {
+	l.capture(msg, fields)
+}
+
+func (l *captureLogger) Debug(msg string, fields ...zap.Field) {
+	l.capture(msg, fields)
+}
+
+func (l *captureLogger) capture(msg string, fields []zap.Field) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, logEntry{msg: msg, fields: fields})
+}
+
+// field returns the value of the first captured string field with given key
+func (l *captureLogger) field(key string) (string, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, e := range l.entries {
+		for _, f := range e.fields {
+			if f.Key == key {
+				return f.String, true
+			}
+		}
+	}
+	return "", false
+}
+
+func newCaptureLogger(t *testing.T) *captureLogger {
+	return &captureLogger{
+		AppLogger: logger.NewTestAppLogger(t.TempDir()),
+	}
+}
+
+func fakeResult(lat, lng float64, addr string, types ...string) maps.GeocodingResult {
+	return maps.GeocodingResult{
+		FormattedAddress: addr,
+		Geometry: maps.AddressGeometry{
+			Location: maps.LatLng{Lat: lat, Lng: lng},
+		},
+		Types: types,
+	}
+}
+
+func TestFakeGeocoder(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"preferred result type is selected, succeeds": testPreferTypes,
+		"empty route responses, fails":                testEmptyRouteResponses,
+		"empty route matrix responses, fails":         testEmptyRouteMatrixResponses,
+		"route instructions language, succeeds":       testRouteLanguage,
+		"failure logs response status, succeeds":      testLogResponseStatus,
+		"fallback to postal code, succeeds":           testFallbackToPostalCode,
+		"truck vehicle profile, succeeds":             testVehicleProfile,
+		"split street unit is opt-in, succeeds":       testSplitStreetUnit,
+		"max alternative routes, succeeds":            testMaxAlternatives,
+		"postal code name, succeeds":                  testPreferPostalCodeName,
+		"nearest road name, succeeds":                 testNearestRoadName,
+		"audit hook receives results, succeeds":       testAuditHook,
+		"route matrix leg indices, succeeds":          testRouteMatrixIndices,
+		"address too long, fails":                     testAddressTooLong,
+		"viewport center location, succeeds":          testViewportCenter,
+		"route region bias, succeeds":                 testRouteRegion,
+		"tied results tie breaker, succeeds":          testTieBreaker,
+		"premise and subpremise, succeeds":            testPremise,
+		"route leg locations, succeeds":               testRouteLegLocations,
+		"mixed route matrix inputs, succeeds":         testRouteMatrixMixed,
+		"results without geometry, fails":             testZeroGeometry,
+		"route speed override, succeeds":              testSpeedOverride,
+		"route leg average speed, succeeds":           testAverageSpeed,
+		"all geocoding candidates, succeeds":          testGeocodeAll,
+		"route travel mode, succeeds":                 testRouteTravelMode,
+		"custom result selection, succeeds":           testSelectResult,
+		"point place id and types, succeeds":          testPointPlaceID,
+		"route preferred modes fallback, succeeds":    testRoutePreferredModes,
+		"route step polylines, succeeds":              testStepPolylines,
+		"route honors context deadline, fails":        testRouteContextDeadline,
+		"same route origin and destination, fails":    testSameOriginDestination,
+		"geocoding language and region, succeeds":     testGeocodeLanguage,
+		"route and matrix units, succeeds":            testRouteUnits,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testPreferTypes(t *testing.T) {
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{
+				fakeResult(37.42, -122.08, "Amphitheatre Pkwy, Mountain View, CA, USA", "route"),
+				fakeResult(37.42, -122.08, "Building 40, Mountain View, CA, USA", "premise"),
+				fakeResult(37.42, -122.08, "1600 Amphitheatre Pkwy, Mountain View, CA, USA", "street_address"),
+			}, nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, c)
+	pt, err := gsc.GeocodeLatLong(ctx, 37.42, -122.08, "")
+	require.NoError(t, err)
+	require.Equal(t, "Amphitheatre Pkwy, Mountain View, CA, USA", pt.FormattedAddress)
+
+	gsc = newFakeService(t, Config{PreferTypes: []string{"street_address", "premise", "route"}}, c)
+	pt, err = gsc.GeocodeLatLong(ctx, 37.42, -122.08, "")
+	require.NoError(t, err)
+	require.Equal(t, "1600 Amphitheatre Pkwy, Mountain View, CA, USA", pt.FormattedAddress)
+}
+
+func testEmptyRouteResponses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+
+	c := &fakeMapsClient{}
+	gsc := newFakeService(t, Config{}, c)
+	routeLegs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.Equal(t, ErrNoRoute, err)
+	require.Nil(t, routeLegs)
+
+	c.directionsFn = func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+		return []maps.Route{{Summary: "no legs"}}, nil, nil
+	}
+	routeLegs, err = gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.Equal(t, ErrEmptyResponse, err)
+	require.Nil(t, routeLegs)
+}
+
+func testEmptyRouteMatrixResponses(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	origins := []*Point{{Latitude: 37.42, Longitude: -122.08}}
+	dests := []*Point{{Latitude: 37.41, Longitude: -122.07}}
+
+	c := &fakeMapsClient{}
+	gsc := newFakeService(t, Config{}, c)
+	routeLegs, err := gsc.GetRouteMatrixForLatLong(ctx, origins, dests)
+	require.Equal(t, ErrEmptyResponse, err)
+	require.Nil(t, routeLegs)
+
+	c.matrixFn = func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+		return &maps.DistanceMatrixResponse{}, nil
+	}
+	routeLegs, err = gsc.GetRouteMatrixForLatLong(ctx, origins, dests)
+	require.Equal(t, ErrEmptyResponse, err)
+	require.Nil(t, routeLegs)
+
+	c.matrixFn = func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+		return &maps.DistanceMatrixResponse{
+			OriginAddresses:      []string{"origin"},
+			DestinationAddresses: []string{"destination"},
+			Rows: []maps.DistanceMatrixElementsRow{
+				{Elements: []*maps.DistanceMatrixElement{{Status: "ZERO_RESULTS"}}},
+			},
+		}, nil
+	}
+	routeLegs, err = gsc.GetRouteMatrixForLatLong(ctx, origins, dests)
+	require.Equal(t, ErrNoRoute, err)
+	require.Nil(t, routeLegs)
+}
+
+func testRouteLanguage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			instr := "Head <b>north</b> on Amphitheatre Pkwy"
+			if r.Language == "es" {
+				instr = "Dirígete hacia el <b>norte</b> por Amphitheatre Pkwy"
+			}
+			return []maps.Route{{
+				Legs: []*maps.Leg{{
+					StartAddress: "1600 Amphitheatre Pkwy",
+					EndAddress:   "1045 La Avenida St",
+					Steps:        []*maps.Step{{HTMLInstructions: instr}},
+				}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+
+	enLegs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	esLegs, err := gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{Language: "es"})
+	require.NoError(t, err)
+	require.Equal(t, "es", c.routeReqs[1].Language)
+
+	require.Equal(t, 1, len(esLegs[0].Steps))
+	require.NotEmpty(t, esLegs[0].Steps[0].Instructions)
+	require.NotEqual(t, enLegs[0].Steps[0].Instructions, esLegs[0].Steps[0].Instructions)
+}
+
+func testLogResponseStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return nil, errors.New("maps: OVER_QUERY_LIMIT - You have exceeded your daily request quota for this API.")
+		},
+	}
+	l := newCaptureLogger(t)
+	gsc := newFakeService(t, Config{AppLogger: l}, c)
+
+	_, err := gsc.GeocodeAddress(ctx, &AddressQuery{PostalCode: "94952"})
+	require.Equal(t, ErrGeoCodeAddress, err)
+	status, ok := l.field("status")
+	require.True(t, ok)
+	require.Equal(t, "OVER_QUERY_LIMIT", status)
+
+	c.geocodeFn = nil
+	l = newCaptureLogger(t)
+	gsc = newFakeService(t, Config{AppLogger: l}, c)
+
+	_, err = gsc.Geocode(ctx, "00000", "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+	status, ok = l.field("status")
+	require.True(t, ok)
+	require.Equal(t, STATUS_ZERO_RESULTS, status)
+}
+
+func testFallbackToPostalCode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.Components[maps.ComponentPostalCode] == "94952" {
+				return []maps.GeocodingResult{
+					fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code"),
+				}, nil
+			}
+			return nil, nil
+		},
+	}
+	addr := &AddressQuery{
+		Street:     "99999 Nowhere Rd",
+		City:       "Petaluma",
+		PostalCode: "94952",
+		State:      "CA",
+	}
+
+	gsc := newFakeService(t, Config{}, c)
+	_, err := gsc.GeocodeAddress(ctx, addr)
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	gsc = newFakeService(t, Config{FallbackToPostalCode: true}, c)
+	pt, meta, err := gsc.GeocodeAddressWithMeta(ctx, addr)
+	require.NoError(t, err)
+	require.True(t, meta.Degraded)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, 38.24, pt.Latitude)
+}
+
+func testVehicleProfile(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{{StartAddress: "origin", EndAddress: "destination"}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+
+	routeLegs, err := gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{
+		Vehicle: &VehicleProfile{Type: CAR, AvoidTolls: true},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []maps.Avoid{maps.AvoidTolls}, c.routeReqs[0].Avoid)
+	require.Empty(t, routeLegs[0].Warnings)
+
+	routeLegs, err = gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{
+		Vehicle: &VehicleProfile{
+			Type:          TRUCK,
+			AvoidHighways: true,
+			AvoidFerries:  true,
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, []maps.Avoid{maps.AvoidHighways, maps.AvoidFerries}, c.routeReqs[1].Avoid)
+	require.Equal(t, []string{WARN_VEHICLE_UNSUPPORTED}, routeLegs[0].Warnings)
+}
+
+func testSplitStreetUnit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(37.42, -122.08, r.Address)}, nil
+		},
+	}
+
+	gsc := newFakeService(t, Config{}, c)
+	addr := &AddressQuery{Street: "Unit 4, 123 Main St", Country: "US"}
+	_, err := gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, "", addr.Unit)
+
+	gsc = newFakeService(t, Config{SplitStreetUnit: true}, c)
+	_, err = gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, "123 Main St #4, US", c.geocodeReqs[1].Address)
+	// the caller's query isn't modified
+	require.Equal(t, "", addr.Unit)
+	require.Equal(t, "Unit 4, 123 Main St", addr.Street)
+}
+
+func testMaxAlternatives(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			routes := []maps.Route{}
+			for _, summary := range []string{"US-101 N", "CA-1 N", "I-280 N", "CA-35 N"} {
+				routes = append(routes, maps.Route{
+					Summary: summary,
+					Legs:    []*maps.Leg{{StartAddress: "origin", EndAddress: "destination"}},
+				})
+			}
+			if !r.Alternatives {
+				routes = routes[:1]
+			}
+			return routes, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.77, Longitude: -122.42}
+
+	routes, err := gsc.GetRoutesForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(routes))
+
+	routes, err = gsc.GetRoutesForLatLongWithOptions(ctx, origin, dest, &RouteOptions{MaxAlternatives: 2})
+	require.NoError(t, err)
+	require.True(t, c.routeReqs[1].Alternatives)
+	require.Equal(t, 2, len(routes))
+	require.Equal(t, "US-101 N", routes[0].Summary)
+	require.Equal(t, "CA-1 N", routes[1].Summary)
+}
+
+func testPreferPostalCodeName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	locality := fakeResult(51.5, -0.12, "London, UK", "locality", "political")
+	district := fakeResult(51.52, -0.1, "London EC1A, UK", "postal_code")
+	district.AddressComponents = []maps.AddressComponent{
+		{LongName: "EC1A", ShortName: "EC1A", Types: []string{"postal_code"}},
+		{LongName: "London", ShortName: "London", Types: []string{"postal_town"}},
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{locality, district}, nil
+		},
+	}
+
+	gsc := newFakeService(t, Config{}, c)
+	pt, err := gsc.Geocode(ctx, "EC1A", "UK")
+	require.NoError(t, err)
+	require.Equal(t, "London, UK", pt.FormattedAddress)
+
+	gsc = newFakeService(t, Config{PreferPostalCodeName: true}, c)
+	pt, err = gsc.Geocode(ctx, "EC1A", "UK")
+	require.NoError(t, err)
+	require.Equal(t, "EC1A", pt.FormattedAddress)
+	require.Equal(t, 51.52, pt.Latitude)
+
+	// the postal code preference is applied ahead of PreferTypes
+	gsc = newFakeService(t, Config{PreferPostalCodeName: true, PreferTypes: []string{"locality"}}, c)
+	pt, err = gsc.Geocode(ctx, "EC1A", "UK")
+	require.NoError(t, err)
+	require.Equal(t, "EC1A", pt.FormattedAddress)
+	require.Equal(t, 51.52, pt.Latitude)
+
+	// the SelectResult callback decides alone
+	gsc = newFakeService(t, Config{
+		PreferPostalCodeName: true,
+		SelectResult: func(results []Result) (*Result, error) {
+			return &results[0], nil
+		},
+	}, c)
+	pt, err = gsc.Geocode(ctx, "EC1A", "UK")
+	require.NoError(t, err)
+	require.Equal(t, "London, UK", pt.FormattedAddress)
+	require.Equal(t, 51.5, pt.Latitude)
+}
+
+func testNearestRoadName(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if r.LatLng.Lat < 0 {
+				return nil, nil
+			}
+			res := fakeResult(37.7749, -122.4194, "Market St, San Francisco, CA, USA", "route")
+			res.AddressComponents = []maps.AddressComponent{
+				{LongName: "Market Street", ShortName: "Market St", Types: []string{"route"}},
+				{LongName: "San Francisco", ShortName: "SF", Types: []string{"locality", "political"}},
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	name, err := gsc.NearestRoadName(ctx, &Point{Latitude: 37.7749, Longitude: -122.4194})
+	require.NoError(t, err)
+	require.Equal(t, "Market Street", name)
+	require.Equal(t, []string{"route"}, c.geocodeReqs[0].ResultType)
+
+	_, err = gsc.NearestRoadName(ctx, &Point{Latitude: -30.0, Longitude: -20.0})
+	require.Equal(t, ErrNoRoad, err)
+}
+
+func testAuditHook(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")
+	res.PlaceID = "ChIJ4Y-yHxxKhIARZgDSsBYNfSo"
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+
+	var ops []string
+	var reqs []any
+	var audited []*GeocoderResults
+	gsc := newFakeService(t, Config{
+		OnResult: func(op string, request any, results *GeocoderResults) {
+			ops = append(ops, op)
+			reqs = append(reqs, request)
+			audited = append(audited, results)
+		},
+	}, c)
+
+	_, err := gsc.Geocode(ctx, "94952", "USA")
+	require.NoError(t, err)
+	require.Equal(t, []string{"Geocode"}, ops)
+	require.Equal(t, &GeocodeRequest{Components: map[string]string{"postal_code": "94952", "country": "USA"}}, reqs[0])
+	require.Equal(t, STATUS_OK, audited[0].Status)
+	require.Equal(t, 1, len(audited[0].Results))
+	require.Equal(t, "Petaluma, CA 94952, USA", audited[0].Results[0].FormattedAddress)
+	require.Equal(t, res.PlaceID, audited[0].Results[0].PlaceId)
+	require.Equal(t, 38.24, audited[0].Results[0].Geometry.Location.Lat)
+}
+
+func testRouteMatrixIndices(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ok := func(m int) *maps.DistanceMatrixElement {
+		return &maps.DistanceMatrixElement{Status: STATUS_OK, Distance: maps.Distance{Meters: m}}
+	}
+	c := &fakeMapsClient{
+		matrixFn: func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+			return &maps.DistanceMatrixResponse{
+				OriginAddresses:      []string{"o0", "o1"},
+				DestinationAddresses: []string{"d0", "d1"},
+				Rows: []maps.DistanceMatrixElementsRow{
+					{Elements: []*maps.DistanceMatrixElement{ok(0), ok(1)}},
+					{Elements: []*maps.DistanceMatrixElement{ok(10), ok(11)}},
+				},
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origins := []*Point{{Latitude: 37.42, Longitude: -122.08}, {Latitude: 37.77, Longitude: -122.41}}
+	dests := []*Point{{Latitude: 37.33, Longitude: -121.89}, {Latitude: 37.80, Longitude: -122.27}}
+	routeLegs, err := gsc.GetRouteMatrixForLatLong(ctx, origins, dests)
+	require.NoError(t, err)
+	require.Equal(t, 4, len(routeLegs))
+	for _, l := range routeLegs {
+		require.Equal(t, fmt.Sprintf("o%d", l.OriginIndex), l.Start)
+		require.Equal(t, fmt.Sprintf("d%d", l.DestIndex), l.End)
+		require.Equal(t, l.OriginIndex*10+l.DestIndex, l.Distance)
+	}
+}
+
+func testAddressTooLong(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{}
+	gsc := newFakeService(t, Config{MaxAddressLength: 64}, c)
+
+	_, err := gsc.GeocodeAddress(ctx, &AddressQuery{
+		Street: strings.Repeat("1600 Amphitheatre Pkwy ", 4),
+		City:   "Mountain View",
+	})
+	require.Equal(t, ErrAddressTooLong, err)
+	require.Equal(t, 0, c.geocodeCalls())
+
+	gsc = newFakeService(t, Config{}, c)
+	require.Equal(t, DEFAULT_MAX_ADDRESS_LENGTH, gsc.MaxAddressLength)
+}
+
+func testViewportCenter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	res := fakeResult(37.8199, -122.4783, "Golden Gate Bridge, San Francisco, CA, USA", "establishment")
+	res.Geometry.Viewport = maps.LatLngBounds{
+		NorthEast: maps.LatLng{Lat: 37.84, Lng: -122.46},
+		SouthWest: maps.LatLng{Lat: 37.80, Lng: -122.48},
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	addr := &AddressQuery{Street: "Golden Gate Bridge", City: "San Francisco"}
+
+	gsc := newFakeService(t, Config{}, c)
+	pt, err := gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, 37.8199, pt.Latitude)
+	require.Equal(t, -122.4783, pt.Longitude)
+
+	gsc = newFakeService(t, Config{UseViewportCenter: true}, c)
+	pt, err = gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.InDelta(t, 37.82, pt.Latitude, 1e-9)
+	require.InDelta(t, -122.47, pt.Longitude, 1e-9)
+}
+
+func testRouteRegion(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			start, end := "Birmingham, AL, USA", "Manchester, NH, USA"
+			if r.Region == "uk" {
+				start, end = "Birmingham, UK", "Manchester, UK"
+			}
+			return []maps.Route{{
+				Legs: []*maps.Leg{{StartAddress: start, EndAddress: end}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &AddressQuery{City: "Birmingham"}
+	dest := &AddressQuery{City: "Manchester"}
+	routeLegs, err := gsc.GetRouteForAddressWithOptions(ctx, origin, dest, &RouteOptions{Region: "uk"})
+	require.NoError(t, err)
+	require.Equal(t, "uk", c.routeReqs[0].Region)
+	require.Equal(t, "Birmingham, UK", routeLegs[0].Start)
+	require.Equal(t, "Manchester, UK", routeLegs[0].End)
+}
+
+func testTieBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	springfield := func(lat, lng float64, state, placeID string) maps.GeocodingResult {
+		r := fakeResult(lat, lng, "Springfield, "+state+", USA", "locality")
+		r.PlaceID = placeID
+		r.Geometry.LocationType = "APPROXIMATE"
+		return r
+	}
+	order := [][]maps.GeocodingResult{
+		{springfield(39.78, -89.65, "IL", "ChIJ_IL"), springfield(37.21, -93.29, "MO", "ChIJ_MO"), springfield(42.10, -72.59, "MA", "ChIJ_MA")},
+		{springfield(42.10, -72.59, "MA", "ChIJ_MA"), springfield(39.78, -89.65, "IL", "ChIJ_IL"), springfield(37.21, -93.29, "MO", "ChIJ_MO")},
+	}
+	call := 0
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			resp := order[call%len(order)]
+			call++
+			return resp, nil
+		},
+	}
+	addr := &AddressQuery{City: "Springfield"}
+
+	for tb, want := range map[TieBreaker]string{
+		TIE_BREAK_PLACE_ID:  "Springfield, IL, USA",
+		TIE_BREAK_NORTHWEST: "Springfield, MA, USA",
+	} {
+		gsc := newFakeService(t, Config{TieBreaker: tb}, c)
+		for i := 0; i < len(order); i++ {
+			pt, err := gsc.GeocodeAddress(ctx, addr)
+			require.NoError(t, err)
+			require.Equal(t, want, pt.FormattedAddress, tb)
+		}
+	}
+}
+
+func testPremise(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			res := fakeResult(37.4220, -122.0841, "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA", "street_address")
+			res.Geometry.LocationType = "RANGE_INTERPOLATED"
+			if strings.HasPrefix(r.Address, "Building 40, ") && r.Components[maps.ComponentPostalCode] == "94043" {
+				res = fakeResult(37.4215, -122.0852, "Building 40, 1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA", "premise")
+				res.Geometry.LocationType = "ROOFTOP"
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	flat, err := gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", PostalCode: "94043"})
+	require.NoError(t, err)
+	require.Nil(t, c.geocodeReqs[0].Components)
+
+	addr := &AddressQuery{Premise: "Building 40", Street: "1600 Amphitheatre Pkwy", City: "Mountain View", PostalCode: "94043"}
+	pt, meta, err := gsc.GeocodeAddressWithFieldMatch(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, "Building 40, 1600 Amphitheatre Pkwy, Mountain View, 94043, USA", c.geocodeReqs[1].Address)
+	require.Equal(t, "USA", c.geocodeReqs[1].Components[maps.ComponentCountry])
+	require.NotEqual(t, flat.FormattedAddress, pt.FormattedAddress)
+	require.Equal(t, "Building 40, 1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA", pt.FormattedAddress)
+	require.Contains(t, meta.FieldMatch, "Premise")
+}
+
+func testRouteLegLocations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{{
+					StartAddress:  "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA",
+					EndAddress:    "1 Infinite Loop, Cupertino, CA 95014, USA",
+					StartLocation: maps.LatLng{Lat: 37.4223, Lng: -122.0846},
+					EndLocation:   maps.LatLng{Lat: 37.3318, Lng: -122.0312},
+				}},
+			}}, []maps.GeocodedWaypoint{
+				{PlaceID: "googleplex", Types: []string{"establishment", "point_of_interest"}},
+				{PlaceID: "infinite-loop", Types: []string{"establishment", "point_of_interest", "premise"}},
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.4220, Longitude: -122.0841}
+	dest := &Point{Latitude: 37.3318, Longitude: -122.0311}
+	routeLegs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(routeLegs))
+	require.Equal(t, LatLng{Lat: 37.4223, Lng: -122.0846}, routeLegs[0].StartLocation)
+	require.Equal(t, LatLng{Lat: 37.3318, Lng: -122.0312}, routeLegs[0].EndLocation)
+	require.Equal(t, []string{"establishment", "point_of_interest"}, routeLegs[0].StartTypes)
+	require.Equal(t, []string{"establishment", "point_of_interest", "premise"}, routeLegs[0].EndTypes)
+
+	d, err := gsc.GetDistance(ctx, METERS, origin, &Point{
+		Latitude:  routeLegs[0].StartLocation.Lat,
+		Longitude: routeLegs[0].StartLocation.Lng,
+	})
+	require.NoError(t, err)
+	require.Less(t, d, 100.0)
+}
+
+func testRouteMatrixMixed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var req *maps.DistanceMatrixRequest
+	c := &fakeMapsClient{
+		matrixFn: func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+			req = r
+			return &maps.DistanceMatrixResponse{
+				OriginAddresses:      []string{"o0"},
+				DestinationAddresses: []string{"d0"},
+				Rows: []maps.DistanceMatrixElementsRow{
+					{Elements: []*maps.DistanceMatrixElement{{Status: STATUS_OK, Distance: maps.Distance{Meters: 42}}}},
+				},
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origins := []MatrixInput{{Point: &Point{Latitude: 37.42, Longitude: -122.08}}}
+	dests := []MatrixInput{{Address: &AddressQuery{Street: "1 Market St", City: "San Francisco", Country: "USA"}}}
+	routeLegs, err := gsc.GetRouteMatrixMixed(ctx, origins, dests)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(routeLegs))
+	require.Equal(t, 42, routeLegs[0].Distance)
+	require.Equal(t, []string{"37.420000 -122.080000"}, req.Origins)
+	require.Equal(t, []string{"1 Market St, San Francisco, USA"}, req.Destinations)
+
+	_, err = gsc.GetRouteMatrixMixed(ctx, origins, []MatrixInput{{}})
+	require.Equal(t, ErrInvalidMatrixInput, err)
+}
+
+func testZeroGeometry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := []maps.GeocodingResult{{FormattedAddress: "Petaluma, CA 94952, USA"}}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return results, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	_, err := gsc.Geocode(ctx, "94952", "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", PostalCode: "94952"})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.GeocodeLatLong(ctx, 38.24, -122.64, "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	results = append(results, fakeResult(38.24, -122.64, "Petaluma, CA, USA", "locality"))
+	pt, err := gsc.Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA, USA", pt.FormattedAddress)
+}
+
+func testSpeedOverride(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{{
+					StartAddress: "origin",
+					EndAddress:   "destination",
+					Distance:     maps.Distance{Meters: 15000},
+					Steps: []*maps.Step{
+						{Distance: maps.Distance{Meters: 5000}},
+						{Distance: maps.Distance{Meters: 10000}},
+					},
+				}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+	routeLegs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, time.Duration(0), routeLegs[0].Duration)
+	require.False(t, routeLegs[0].Estimated)
+
+	routes, err := gsc.GetRoutesForLatLongWithOptions(ctx, origin, dest, &RouteOptions{
+		SpeedOverride: map[TravelMode]float64{DRIVING: 60, WALKING: 5},
+	})
+	require.NoError(t, err)
+	leg := routes[0].Legs[0]
+	require.True(t, leg.Estimated)
+	require.Equal(t, 15*time.Minute, leg.Duration)
+	require.Equal(t, 5*time.Minute, leg.Steps[0].Duration)
+	require.Equal(t, 15*time.Minute, routes[0].Duration)
+
+	routeLegs, err = gsc.GetRouteForLatLongWithOptions(ctx, origin, dest, &RouteOptions{
+		SpeedOverride: map[TravelMode]float64{WALKING: 5},
+	})
+	require.NoError(t, err)
+	require.False(t, routeLegs[0].Estimated)
+}
+
+func testAverageSpeed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{
+					{Distance: maps.Distance{Meters: 45000}, Duration: 30 * time.Minute},
+					{Distance: maps.Distance{Meters: 1000}},
+				},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	routeLegs, err := gsc.GetRouteForLatLong(ctx, &Point{Latitude: 37.42, Longitude: -122.08}, &Point{Latitude: 37.41, Longitude: -122.07})
+	require.NoError(t, err)
+	require.Equal(t, 2, len(routeLegs))
+	require.InDelta(t, 90.0, routeLegs[0].AverageSpeedKmh, 1e-9)
+	require.Equal(t, 0.0, routeLegs[1].AverageSpeedKmh)
+}
+
+func testGeocodeAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			il := fakeResult(39.78, -89.65, "Springfield, IL, USA", "locality")
+			il.PlaceID = "il"
+			ma := fakeResult(42.1, -72.59, "Springfield, MA, USA", "locality")
+			ma.PlaceID = "ma"
+			mo := fakeResult(37.21, -93.29, "Springfield, MO, USA", "locality")
+			mo.PlaceID = "mo"
+			return []maps.GeocodingResult{il, ma, mo, il}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	pts, err := gsc.GeocodeAll(ctx, "Springfield")
+	require.NoError(t, err)
+	require.Equal(t, 4, len(pts))
+	require.Equal(t, "Springfield, IL, USA", pts[0].FormattedAddress)
+	require.Equal(t, "Springfield, MA, USA", pts[1].FormattedAddress)
+	require.Equal(t, "Springfield, MO, USA", pts[2].FormattedAddress)
+	require.Equal(t, "Springfield", c.geocodeReqs[0].Address)
+
+	// the country defaults on a copy, the caller's queries are left as given
+	addr := &AddressQuery{City: "Springfield"}
+	top, err := gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, pts[0], top)
+	require.Equal(t, "", addr.Country)
+
+	gsc.DedupePlaceIDs = true
+	pts, err = gsc.GeocodeAddressAll(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(pts))
+	require.Equal(t, &AddressQuery{City: "Springfield"}, addr)
+
+	_, err = gsc.GeocodeAll(ctx, "  ")
+	require.Equal(t, ErrInvalidAddress, err)
+}
+
+func testRouteTravelMode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			leg := &maps.Leg{Distance: maps.Distance{Meters: 2500}, Duration: 6 * time.Minute}
+			if r.Mode == maps.TravelModeWalking {
+				leg = &maps.Leg{Distance: maps.Distance{Meters: 1800}, Duration: 25 * time.Minute}
+			}
+			return []maps.Route{{Legs: []*maps.Leg{leg}}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA"}
+	dest := &AddressQuery{Street: "1 Charleston Park", City: "Mountain View", State: "CA"}
+	driving, err := gsc.GetRouteForAddress(ctx, origin, dest)
+	require.NoError(t, err)
+	walking, err := gsc.GetRouteForAddressWithOptions(ctx, origin, dest, &RouteOptions{Mode: WALKING})
+	require.NoError(t, err)
+	require.Equal(t, maps.Mode(""), c.routeReqs[0].Mode)
+	require.Equal(t, maps.TravelModeWalking, c.routeReqs[1].Mode)
+	require.NotEqual(t, driving[0].Distance, walking[0].Distance)
+	require.Greater(t, walking[0].Duration, driving[0].Duration)
+
+	_, err = gsc.GetRouteForAddressWithOptions(ctx, origin, dest, &RouteOptions{Mode: "FLYING"})
+	require.Equal(t, ErrInvalidTravelMode, err)
+	require.Equal(t, 2, len(c.routeReqs))
+}
+
+func testSelectResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{
+				fakeResult(37.42, -122.08, "Mountain View, CA, USA", "locality", "political"),
+				fakeResult(37.39, -122.08, "Mountain View, CA 94041, USA", "postal_code"),
+				fakeResult(37.4, -122.09, "Mountain View Station, Mountain View, CA, USA", "transit_station"),
+			}, nil
+		},
+	}
+	var seen int
+	gsc := newFakeService(t, Config{
+		SelectResult: func(results []Result) (*Result, error) {
+			seen = len(results)
+			for i, r := range results {
+				for _, typ := range r.Types {
+					if typ == "transit_station" {
+						return &results[i], nil
+					}
+				}
+			}
+			return nil, nil
+		},
+	}, c)
+
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Mountain View", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, 3, seen)
+	require.Equal(t, "Mountain View Station, Mountain View, CA, USA", pt.FormattedAddress)
+	require.Equal(t, 37.4, pt.Latitude)
+	require.True(t, gsc.EffectiveConfig().SelectResult)
+
+	selectErr := errors.New("no acceptable result")
+	gsc.SelectResult = func(results []Result) (*Result, error) {
+		return nil, selectErr
+	}
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Sunnyvale", State: "CA"})
+	require.Equal(t, selectErr, err)
+
+	gsc.SelectResult = func(results []Result) (*Result, error) {
+		return nil, nil
+	}
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Cupertino", State: "CA"})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+}
+
+func testPointPlaceID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")
+			res.PlaceID = "ChIJ-postal"
+			if r.Address != "" {
+				res = fakeResult(38.23, -122.63, "1 Kentucky St, Petaluma, CA 94952, USA", "street_address")
+				res.PlaceID = "ChIJ-address"
+			}
+			if r.LatLng != nil {
+				res = fakeResult(r.LatLng.Lat, r.LatLng.Lng, "Kentucky St, Petaluma, CA 94952, USA", "route")
+				res.PlaceID = "ChIJ-route"
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+
+	pt, err := gsc.Geocode(ctx, "94952", "US")
+	require.NoError(t, err)
+	require.Equal(t, "ChIJ-postal", pt.PlaceID)
+	require.Equal(t, []string{"postal_code"}, pt.Types)
+
+	pt, err = gsc.GeocodeAddress(ctx, &AddressQuery{Street: "1 Kentucky St", City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, "ChIJ-address", pt.PlaceID)
+	require.Equal(t, []string{"street_address"}, pt.Types)
+
+	pt, err = gsc.GeocodeLatLong(ctx, 38.23, -122.63, "")
+	require.NoError(t, err)
+	require.Equal(t, "ChIJ-route", pt.PlaceID)
+	require.Equal(t, []string{"route"}, pt.Types)
+
+	// cached points are copies
+	pt.Types[0] = "changed"
+	pt, err = gsc.GeocodeLatLong(ctx, 38.23, -122.63, "")
+	require.NoError(t, err)
+	require.Equal(t, []string{"route"}, pt.Types)
+	require.Equal(t, 3, c.geocodeCalls())
+}
+
+func testRoutePreferredModes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			if r.Mode == maps.TravelModeTransit {
+				// ZERO_RESULTS
+				return nil, nil, nil
+			}
+			return []maps.Route{{
+				Summary: string(r.Mode),
+				Legs:    []*maps.Leg{{StartAddress: "origin", EndAddress: string(r.Mode)}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+	legs, mode, err := gsc.GetRoutePreferredModes(ctx, origin, dest, []TravelMode{TRANSIT, WALKING, DRIVING})
+	require.NoError(t, err)
+	require.Equal(t, WALKING, mode)
+	require.Equal(t, string(maps.TravelModeWalking), legs[0].End)
+	require.Equal(t, 2, len(c.routeReqs))
+
+	_, _, err = gsc.GetRoutePreferredModes(ctx, origin, dest, []TravelMode{TRANSIT})
+	require.Equal(t, ErrNoRoute, err)
+
+	_, _, err = gsc.GetRoutePreferredModes(ctx, origin, dest, []TravelMode{"HOVERCRAFT"})
+	require.Equal(t, ErrInvalidTravelMode, err)
+
+	_, mode, err = gsc.GetRoutePreferredModes(ctx, origin, dest, nil)
+	require.NoError(t, err)
+	require.Equal(t, DRIVING, mode)
+}
+
+func testStepPolylines(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	path := []maps.LatLng{
+		{Lat: 37.4220, Lng: -122.0841},
+		{Lat: 37.4000, Lng: -122.0700},
+		{Lat: 37.3700, Lng: -122.0500},
+		{Lat: 37.3318, Lng: -122.0311},
+	}
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				OverviewPolyline: maps.Polyline{Points: maps.Encode([]maps.LatLng{path[0], path[3]})},
+				Legs: []*maps.Leg{{
+					StartAddress: "origin",
+					EndAddress:   "destination",
+					Steps: []*maps.Step{
+						{Polyline: maps.Polyline{Points: maps.Encode(path[:2])}},
+						{Polyline: maps.Polyline{Points: maps.Encode(path[1:])}},
+						{Distance: maps.Distance{Meters: 10}},
+					},
+				}},
+			}}, nil, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	routes, err := gsc.GetRoutesForLatLong(ctx, &Point{Latitude: 37.42, Longitude: -122.08}, &Point{Latitude: 37.33, Longitude: -122.03})
+	require.NoError(t, err)
+	steps := routes[0].Legs[0].Steps
+	require.Equal(t, 3, len(steps))
+	require.Equal(t, 2, len(steps[0].Polyline))
+	require.Equal(t, 3, len(steps[1].Polyline))
+	require.Nil(t, steps[2].Polyline)
+
+	overview, err := maps.DecodePolyline(routes[0].Polyline)
+	require.NoError(t, err)
+	first, last := steps[0].Polyline[0], steps[1].Polyline[len(steps[1].Polyline)-1]
+	require.InDelta(t, overview[0].Lat, first.Lat, 1e-5)
+	require.InDelta(t, overview[0].Lng, first.Lng, 1e-5)
+	require.InDelta(t, overview[len(overview)-1].Lat, last.Lat, 1e-5)
+	require.InDelta(t, overview[len(overview)-1].Lng, last.Lng, 1e-5)
+}
+
+func testRouteContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			// a hung directions call
+			select {}
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08}
+	dest := &Point{Latitude: 37.41, Longitude: -122.07}
+	_, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+
+	_, err = gsc.GetRouteForAddress(ctx, &AddressQuery{City: "Petaluma"}, &AddressQuery{City: "Cotati"})
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+}
+
+func testSameOriginDestination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.42, Longitude: -122.08, FormattedAddress: "Googleplex"}
+	dest := &Point{Latitude: 37.420001, Longitude: -122.080001, FormattedAddress: "Googleplex"}
+	_, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.Equal(t, ErrSameOriginDestination, err)
+	_, err = gsc.GetRoutesForLatLong(ctx, origin, origin)
+	require.Equal(t, ErrSameOriginDestination, err)
+	require.Equal(t, 0, len(c.routeReqs))
+
+	gsc = newFakeService(t, Config{AllowSameOriginDestination: true}, c)
+	legs, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(legs))
+	require.Equal(t, 0, legs[0].Distance)
+	require.Equal(t, time.Duration(0), legs[0].Duration)
+	require.Equal(t, "Googleplex", legs[0].Start)
+	require.Equal(t, 0, len(c.routeReqs))
+
+	// points apart are routed
+	_, err = gsc.GetRouteForLatLong(ctx, origin, &Point{Latitude: 37.41, Longitude: -122.07})
+	require.Equal(t, ErrNoRoute, err)
+	require.Equal(t, 1, len(c.routeReqs))
+}
+
+func testGeocodeLanguage(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			addr := "Cologne, Germany"
+			if r.Language == "de" {
+				addr = "Köln, Deutschland"
+			}
+			return []maps.GeocodingResult{fakeResult(50.94, 6.96, addr, "locality")}, nil
+		},
+	}
+	en := newFakeService(t, Config{Language: "en", Region: "de"}, c)
+	de := newFakeService(t, Config{Language: "de", Region: "de"}, c)
+
+	q := &AddressQuery{City: "Köln", Country: "DE"}
+	enPt, err := en.GeocodeAddress(ctx, q)
+	require.NoError(t, err)
+	dePt, err := de.GeocodeAddress(ctx, q)
+	require.NoError(t, err)
+	require.Equal(t, "Cologne, Germany", enPt.FormattedAddress)
+	require.Equal(t, "Köln, Deutschland", dePt.FormattedAddress)
+	require.NotEqual(t, enPt.FormattedAddress, dePt.FormattedAddress)
+	require.Equal(t, "de", c.geocodeReqs[0].Region)
+
+	_, err = de.GeocodeLatLong(ctx, 50.94, 6.96, "")
+	require.NoError(t, err)
+	require.Equal(t, "de", c.geocodeReqs[2].Language)
+	require.Equal(t, "de", de.EffectiveConfig().Language)
+}
+
+func testRouteUnits(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	distance := func(u maps.Units) maps.Distance {
+		if u == maps.UnitsImperial {
+			return maps.Distance{Meters: 19000, HumanReadable: "11.8 mi"}
+		}
+		return maps.Distance{Meters: 19000, HumanReadable: "19.0 km"}
+	}
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Legs: []*maps.Leg{{StartAddress: "Petaluma, CA", EndAddress: "Novato, CA", Distance: distance(r.Units)}},
+			}}, nil, nil
+		},
+		matrixFn: func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+			return &maps.DistanceMatrixResponse{
+				OriginAddresses:      []string{"Petaluma, CA"},
+				DestinationAddresses: []string{"Novato, CA"},
+				Rows: []maps.DistanceMatrixElementsRow{{
+					Elements: []*maps.DistanceMatrixElement{{Status: STATUS_OK, Distance: distance(r.Units)}},
+				}},
+			}, nil
+		},
+	}
+
+	origin, dest := &Point{Latitude: 38.24, Longitude: -122.64}, &Point{Latitude: 38.1, Longitude: -122.57}
+	for units, text := range map[Units]string{IMPERIAL: "11.8 mi", METRIC: "19.0 km"} {
+		gsc := newFakeService(t, Config{Units: units}, c)
+
+		route, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+		require.NoError(t, err)
+		matrix, err := gsc.GetRouteMatrixForLatLong(ctx, []*Point{origin}, []*Point{dest})
+		require.NoError(t, err)
+		require.Equal(t, text, route[0].DistanceText)
+		require.Equal(t, route[0].DistanceText, matrix[0].DistanceText)
+		require.Equal(t, c.routeReqs[len(c.routeReqs)-1].Units, c.matrixReqs[len(c.matrixReqs)-1].Units)
+	}
+
+	gsc := newFakeService(t, Config{Units: Units("NAUTICAL")}, c)
+	_, err := gsc.GetRouteForLatLong(ctx, origin, dest)
+	require.Equal(t, ErrInvalidUnits, err)
+	_, err = gsc.GetRouteMatrixForLatLong(ctx, []*Point{origin}, []*Point{dest})
+	require.Equal(t, ErrInvalidUnits, err)
+}
@@ -90,6 +90,8 @@ func testGeocodePostalcode(t *testing.T, client geocode.GeoCoder) {
 	require.NoError(t, err)
 	require.Equal(t, "33.66", fmt.Sprintf("%0.2f", pt.Latitude))
 	require.Equal(t, "-117.83", fmt.Sprintf("%0.2f", pt.Longitude))
+	require.NotEmpty(t, pt.PlaceID)
+	require.Contains(t, pt.Types, "postal_code")
 }
 
 func testGeocodeLatLong(t *testing.T, client geocode.GeoCoder) {
@@ -104,6 +106,7 @@ func testGeocodeLatLong(t *testing.T, client geocode.GeoCoder) {
 
 	pt, err = client.GeocodeLatLong(ctx, pt.Latitude, pt.Longitude, "Irvine")
 	require.NoError(t, err)
+	require.NotEmpty(t, pt.PlaceID)
 	fmt.Printf("pt: %v\n", pt)
 }
 
@@ -255,6 +258,8 @@ func testGeocodeAddress(t *testing.T, client geocode.GeoCoder) {
 	pt, err = client.GeocodeAddress(ctx, &address)
 	require.NoError(t, err)
 	assert.Equal(t, pt.FormattedAddress, "2001 Market St, San Francisco, CA 94114, USA", "returned address should match")
+	assert.NotEmpty(t, pt.PlaceID)
+	assert.Contains(t, pt.Types, "street_address")
 	t.Logf("geo located to %v", pt)
 
 	address = geocode.AddressQuery{
@@ -0,0 +1,58 @@
+package geocode
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+
+	"github.com/comfforts/logger"
+)
+
+type correlationIDKey struct{}
+
+// WithCorrelationID returns a copy of ctx carrying a correlation ID added to geocode log lines
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+func correlationID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(correlationIDKey{}).(string)
+	return id
+}
+
+// ctxLogger adds request scoped fields to each log line, it wraps rather than embeds the logger
+// so every AppLogger method has to add them
+type ctxLogger struct {
+	l      logger.AppLogger
+	fields []zap.Field
+}
+
+func (l *ctxLogger) Info(msg string, fields ...zap.Field) {
+	l.l.Info(msg, append(fields, l.fields...)...)
+}
+
+func (l *ctxLogger) Error(msg string, fields ...zap.Field) {
+	l.l.Error(msg, append(fields, l.fields...)...)
+}
+
+func (l *ctxLogger) Debug(msg string, fields ...zap.Field) {
+	l.l.Debug(msg, append(fields, l.fields...)...)
+}
+
+func (l *ctxLogger) Fatal(msg string, fields ...zap.Field) {
+	l.l.Fatal(msg, append(fields, l.fields...)...)
+}
+
+func (g *geoCodeService) log(ctx context.Context) logger.AppLogger {
+	id := correlationID(ctx)
+	if id == "" {
+		return g.AppLogger
+	}
+	return &ctxLogger{
+		l:      g.AppLogger,
+		fields: []zap.Field{zap.String("correlation_id", id)},
+	}
+}
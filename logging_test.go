@@ -0,0 +1,44 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCorrelationID(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	l := newCaptureLogger(t)
+	gsc := newFakeService(t, Config{AppLogger: l}, &fakeMapsClient{})
+
+	_, err := gsc.Geocode(ctx, "00000", "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+	_, ok := l.field("correlation_id")
+	require.False(t, ok)
+
+	_, err = gsc.Geocode(WithCorrelationID(ctx, "req-123"), "00000", "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+	id, ok := l.field("correlation_id")
+	require.True(t, ok)
+	require.Equal(t, "req-123", id)
+
+	// every logger method adds it, as do subclient construction failures
+	l = newCaptureLogger(t)
+	gsc = newFakeService(t, Config{AppLogger: l}, &fakeMapsClient{})
+	gsc.log(WithCorrelationID(ctx, "req-456")).Debug("debug line")
+	id, ok = l.field("correlation_id")
+	require.True(t, ok)
+	require.Equal(t, "req-456", id)
+
+	l = newCaptureLogger(t)
+	gsc, err = NewGeoCodeService(Config{Provider: &fakeProvider{}, AppLogger: l})
+	require.NoError(t, err)
+	_, err = gsc.FindPlace(WithCorrelationID(ctx, "req-789"), "coffee")
+	require.Error(t, err)
+	id, ok = l.field("correlation_id")
+	require.True(t, ok)
+	require.Equal(t, "req-789", id)
+}
@@ -2,7 +2,13 @@ package geocode
 
 import (
 	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
+
+	"googlemaps.github.io/maps"
 )
 
 type DistanceUnit string
@@ -14,6 +20,52 @@ const (
 	FEET   DistanceUnit = "FEET"
 )
 
+// DualDistance a distance in metric and imperial units
+type DualDistance struct {
+	Meters     float64 `json:"meters"`
+	Kilometers float64 `json:"kilometers"`
+	Miles      float64 `json:"miles"`
+	Feet       float64 `json:"feet"`
+}
+
+func (u DistanceUnit) isValid() bool {
+	switch u {
+	case KM, MILES, METERS, FEET:
+		return true
+	default:
+		return false
+	}
+}
+
+// DistanceMethod straight line distance formula
+type DistanceMethod string
+
+const (
+	// VINCENTY ellipsoidal distance, accurate to the millimeter
+	VINCENTY DistanceMethod = "VINCENTY"
+	// HAVERSINE great circle distance on a sphere, faster and within about 0.5% of vincenty
+	HAVERSINE DistanceMethod = "HAVERSINE"
+)
+
+// TieBreaker orders geocoding results tied in relevance
+type TieBreaker string
+
+const (
+	// TIE_BREAK_PLACE_ID picks the lexically smallest place id
+	TIE_BREAK_PLACE_ID TieBreaker = "PLACE_ID"
+	// TIE_BREAK_NORTHWEST picks the northernmost, then westernmost, result
+	TIE_BREAK_NORTHWEST TieBreaker = "NORTHWEST"
+)
+
+func (tb TieBreaker) isValid() bool {
+	switch tb {
+	case "", TIE_BREAK_PLACE_ID, TIE_BREAK_NORTHWEST:
+		return true
+	default:
+		return false
+	}
+}
+
 type GeocoderResults struct {
 	Results []Result `json:"results"`
 	Status  string   `json:"status"`
@@ -25,6 +77,7 @@ type Result struct {
 	Geometry          Geometry  `json:"geometry"`
 	PlaceId           string    `json:"place_id"`
 	Types             []string  `json:"types"`
+	PartialMatch      bool      `json:"partial_match,omitempty"`
 }
 
 // Address store each address is identified by the 'types'
@@ -54,14 +107,91 @@ type LatLng struct {
 	Lng float64 `json:"lng"`
 }
 
+// Datum geodetic reference system a coordinate is expressed in
+type Datum string
+
+const (
+	WGS84  Datum = "WGS84"
+	NAD83  Datum = "NAD83"
+	ETRS89 Datum = "ETRS89"
+)
+
 type Point struct {
 	Latitude         float64 `json:"latitude"`
 	Longitude        float64 `json:"longitude"`
 	FormattedAddress string  `json:"formatted_address"`
+	// Datum of the coordinates, empty means WGS84 which is what the geocoding API returns
+	Datum Datum `json:"datum,omitempty"`
+	// PlaceID and Types of the geocoding result the point was resolved from, empty otherwise
+	PlaceID string   `json:"place_id,omitempty"`
+	Types   []string `json:"types,omitempty"`
+}
+
+// Key returns a deterministic key of the coordinates rounded to precision decimal places
+func (p *Point) Key(precision int) string {
+	return coordKey(p.Latitude, p.Longitude, precision)
+}
+
+func coordKey(lat, lng float64, precision int) string {
+	if precision < 0 {
+		precision = 0
+	}
+	round := func(v float64) string {
+		scale := math.Pow(10, float64(precision))
+		v = math.Round(v*scale) / scale
+		if v == 0 {
+			// avoid distinct keys for -0
+			v = 0
+		}
+		return strconv.FormatFloat(v, 'f', precision, 64)
+	}
+	return round(lat) + "," + round(lng)
 }
 
+// GetDatum returns the point's datum, defaulting to WGS84
+func (p *Point) GetDatum() Datum {
+	if p.Datum == "" {
+		return WGS84
+	}
+	return p.Datum
+}
+
+// ToDatum returns a copy of the point expressed in datum d,
+// conversions between different datums are not supported yet
+func (p *Point) ToDatum(d Datum) (*Point, error) {
+	if d == "" {
+		d = WGS84
+	}
+	if p.GetDatum() != d {
+		return nil, ErrUnsupportedDatum
+	}
+	cp := *p
+	cp.Datum = d
+	return &cp, nil
+}
+
+// IsValid reports whether the coordinates are within range, latitude [-90, 90] and longitude [-180, 180],
+// zero is a valid coordinate on the equator or prime meridian, a nil *Point stands for unset and isn't valid
 func (p *Point) IsValid() bool {
-	return p.Latitude != 0 && p.Longitude != 0
+	if p == nil {
+		return false
+	}
+	return validLatLng(p.Latitude, p.Longitude)
+}
+
+func validLatLng(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180
+}
+
+// GeocodeMeta details how a geocoding result was resolved
+type GeocodeMeta struct {
+	// Degraded is set when the result is from a coarser fallback, e.g. the postal code centroid
+	Degraded bool
+	// Stale is set for cached results older than Config.StaleAfter
+	Stale bool
+	// FieldMatch reports for each requested AddressQuery field, keyed by field name,
+	// whether the result's address components matched it
+	FieldMatch map[string]bool
 }
 
 type Range struct {
@@ -73,53 +203,314 @@ type RangeBounds struct {
 	Longitude Range
 }
 
+type Route struct {
+	Summary  string
+	Legs     []*RouteLeg
+	Duration time.Duration
+	Distance int
+	// Polyline encoded overview path of the route
+	Polyline string
+}
+
 type RouteLeg struct {
 	Start    string
 	End      string
 	Duration time.Duration
 	Distance int
+	// DistanceText human readable distance in Config.Units, e.g. "12.1 mi"
+	DistanceText string
+	Steps        []*RouteStep
+	Warnings     []string
+	// StartLocation and EndLocation coordinates of the leg's endpoints, zero for matrix legs
+	StartLocation LatLng
+	EndLocation   LatLng
+	// StartTypes and EndTypes place types, e.g. street_address or establishment, of the leg's
+	// endpoints as geocoded by the directions api, empty for matrix legs
+	StartTypes []string
+	EndTypes   []string
+	// OriginIndex and DestIndex position of the leg's origin and destination
+	// in a route matrix request, zero for directions legs
+	OriginIndex int
+	DestIndex   int
+	// Estimated is set when Duration is computed from a RouteOptions.SpeedOverride
+	Estimated bool
+	// AverageSpeedKmh implied average speed over the leg, Distance over Duration, zero without a duration
+	AverageSpeedKmh float64
+}
+
+// MatrixInput a route matrix origin or destination, exactly one of Point or Address is set
+type MatrixInput struct {
+	Point   *Point
+	Address *AddressQuery
+}
+
+type RouteStep struct {
+	Instructions string
+	Duration     time.Duration
+	Distance     int
+	// Polyline decoded path of the step, for highlighting the current maneuver
+	Polyline []LatLng
+}
+
+// RouteOptions optional per request route settings
+type RouteOptions struct {
+	// Language of the returned route instructions, API default when empty
+	Language string
+	// Vehicle routing profile, defaults to car
+	Vehicle *VehicleProfile
+	// MaxAlternatives requests alternative routes, returning at most this many routes
+	MaxAlternatives int
+	// Region ccTLD code, e.g. "uk", biasing how ambiguous origin and destination strings resolve
+	Region string
+	// Mode of transport, defaults to DRIVING
+	Mode TravelMode
+	// SpeedOverride average speed in km/h by travel mode, when set for the route's mode
+	// leg and step durations are estimated from their distance instead of using the api's
+	SpeedOverride map[TravelMode]float64
+	// DepartureTime and ArrivalTime, "now" or unix seconds, request a traffic aware route, which isn't cached
+	DepartureTime string
+	ArrivalTime   string
+}
+
+// TravelMode mode of transport used for routing, empty means DRIVING
+type TravelMode string
+
+const (
+	DRIVING   TravelMode = "DRIVING"
+	WALKING   TravelMode = "WALKING"
+	BICYCLING TravelMode = "BICYCLING"
+	TRANSIT   TravelMode = "TRANSIT"
+)
+
+// orDefault returns the mode, DRIVING when it's empty
+func (m TravelMode) orDefault() TravelMode {
+	if m == "" {
+		return DRIVING
+	}
+	return m
+}
+
+func (m TravelMode) mapsMode() (maps.Mode, bool) {
+	switch m {
+	case "", DRIVING:
+		return maps.TravelModeDriving, true
+	case WALKING:
+		return maps.TravelModeWalking, true
+	case BICYCLING:
+		return maps.TravelModeBicycling, true
+	case TRANSIT:
+		return maps.TravelModeTransit, true
+	default:
+		return "", false
+	}
+}
+
+// Units unit system of human readable route distances, empty uses the api's default for the origin's country
+type Units string
+
+const (
+	METRIC   Units = "METRIC"
+	IMPERIAL Units = "IMPERIAL"
+)
+
+func (u Units) mapsUnits() (maps.Units, bool) {
+	switch u {
+	case "":
+		return "", true
+	case METRIC:
+		return maps.UnitsMetric, true
+	case IMPERIAL:
+		return maps.UnitsImperial, true
+	default:
+		return "", false
+	}
+}
+
+type VehicleType string
+
+const (
+	CAR   VehicleType = "CAR"
+	TRUCK VehicleType = "TRUCK"
+)
+
+// VehicleProfile describes the routed vehicle.
+// Google directions only supports the avoid modifiers, TRUCK routes fall back to
+// default car routing and carry a warning.
+type VehicleProfile struct {
+	Type          VehicleType
+	AvoidTolls    bool
+	AvoidHighways bool
+	AvoidFerries  bool
+}
+
+func (v *VehicleProfile) avoid() []string {
+	avoid := []string{}
+	if v.AvoidTolls {
+		avoid = append(avoid, string(maps.AvoidTolls))
+	}
+	if v.AvoidHighways {
+		avoid = append(avoid, string(maps.AvoidHighways))
+	}
+	if v.AvoidFerries {
+		avoid = append(avoid, string(maps.AvoidFerries))
+	}
+	return avoid
 }
 
 type AddressQuery struct {
 	Street     string
+	Unit       string
 	City       string
 	PostalCode string
 	State      string
 	Country    string
+	// Premise building or campus name, e.g. "Building 40"
+	Premise string
+	// Subpremise unit within the premise, e.g. "Suite 210"
+	Subpremise string
 }
 
-func (a *AddressQuery) addressString() string {
-	compStr := ""
-	if a.Street != "" {
-		compStr = a.Street
+// IsValid reports whether the query has a street, premise, city or postal code to geocode
+func (a *AddressQuery) IsValid() bool {
+	return strings.TrimSpace(a.Street) != "" ||
+		strings.TrimSpace(a.Premise) != "" ||
+		strings.TrimSpace(a.City) != "" ||
+		strings.TrimSpace(a.PostalCode) != ""
+}
+
+// unitDesignator unit designator capturing its number in one of three groups, the designator word ends
+// at a word boundary or runs into the number, so street names like "Steiner" or "Unitas" aren't split
+const unitDesignator = `(?:(?i:unit|apt|apartment|suite|ste)(?:\b\s*#?\s*([\w-]+)|(\d[\w-]*))|#\s*([\w-]+))`
+
+var (
+	leadingUnit  = regexp.MustCompile(`^` + unitDesignator + `\s*,\s*(.+)$`)
+	trailingUnit = regexp.MustCompile(`^(.+?)\s*,?\s+` + unitDesignator + `$`)
+	// houseNumber leading house number, e.g. "123", "12B" or "1600-1610", but not "1st"
+	houseNumber = regexp.MustCompile(`^\d+[A-Za-z]?(?:-\d+[A-Za-z]?)?\s+`)
+)
+
+// streetName returns the street line without its unit and house number, e.g. "Main St" for "Unit 4, 123 Main St"
+func streetName(street string) string {
+	q := AddressQuery{Street: street}
+	q.splitUnit()
+	return houseNumber.ReplaceAllString(strings.TrimSpace(q.Street), "")
+}
+
+// splitUnit moves a unit/subpremise designator, e.g. "Unit 4, 123 Main St", from Street into Unit
+func (a *AddressQuery) splitUnit() {
+	if a.Unit != "" || a.Street == "" {
+		return
 	}
-	if a.City != "" {
-		if compStr == "" {
-			compStr = a.City
-		} else {
-			compStr = fmt.Sprintf("%s %s", compStr, a.City)
+	street := strings.TrimSpace(a.Street)
+	if m := leadingUnit.FindStringSubmatch(street); m != nil {
+		a.Unit, a.Street = firstMatch(m[1:4]), m[4]
+		return
+	}
+	if m := trailingUnit.FindStringSubmatch(street); m != nil {
+		a.Street, a.Unit = m[1], firstMatch(m[2:5])
+	}
+}
+
+// firstMatch returns the first non empty of the captured groups
+func firstMatch(groups []string) string {
+	for _, g := range groups {
+		if g != "" {
+			return g
 		}
 	}
-	if a.State != "" {
-		if compStr == "" {
-			compStr = a.State
-		} else {
-			compStr = fmt.Sprintf("%s %s", compStr, a.State)
+	return ""
+}
+
+// AddressField a component of the address string sent for geocoding
+type AddressField string
+
+const (
+	// FIELD_PREMISE subpremise and premise
+	FIELD_PREMISE AddressField = "PREMISE"
+	// FIELD_STREET street with its unit
+	FIELD_STREET      AddressField = "STREET"
+	FIELD_CITY        AddressField = "CITY"
+	FIELD_STATE       AddressField = "STATE"
+	FIELD_POSTAL_CODE AddressField = "POSTAL_CODE"
+	FIELD_COUNTRY     AddressField = "COUNTRY"
+)
+
+func (f AddressField) isValid() bool {
+	switch f {
+	case FIELD_PREMISE, FIELD_STREET, FIELD_CITY, FIELD_STATE, FIELD_POSTAL_CODE, FIELD_COUNTRY:
+		return true
+	default:
+		return false
+	}
+}
+
+// DEFAULT_ADDRESS_FIELD_ORDER most to least specific, as used by most western locales
+var DEFAULT_ADDRESS_FIELD_ORDER = []AddressField{
+	FIELD_PREMISE,
+	FIELD_STREET,
+	FIELD_CITY,
+	FIELD_STATE,
+	FIELD_POSTAL_CODE,
+	FIELD_COUNTRY,
+}
+
+func (a *AddressQuery) addressString() string {
+	return a.formatAddress(nil)
+}
+
+// resolveFieldOrder returns the full field order, order's fields without duplicates
+// followed by those it's missing in their DEFAULT_ADDRESS_FIELD_ORDER order
+func resolveFieldOrder(order []AddressField) []AddressField {
+	seen := map[AddressField]bool{}
+	fields := make([]AddressField, 0, len(DEFAULT_ADDRESS_FIELD_ORDER))
+	for _, f := range append(append([]AddressField{}, order...), DEFAULT_ADDRESS_FIELD_ORDER...) {
+		if !seen[f] {
+			seen[f] = true
+			fields = append(fields, f)
 		}
 	}
-	if a.PostalCode != "" {
-		if compStr == "" {
-			compStr = a.PostalCode
+	return fields
+}
+
+// formatAddress comma separates the query's fields in their resolved order, see resolveFieldOrder,
+// a postal code following the state shares its part
+func (a *AddressQuery) formatAddress(order []AddressField) string {
+	parts := []string{}
+	var prev AddressField
+	for _, f := range resolveFieldOrder(order) {
+		v := a.field(f)
+		if v == "" {
+			continue
+		}
+		if f == FIELD_POSTAL_CODE && prev == FIELD_STATE {
+			// state and postal code are one component, e.g. "CA 94043"
+			parts[len(parts)-1] += " " + v
 		} else {
-			compStr = fmt.Sprintf("%s %s", compStr, a.PostalCode)
+			parts = append(parts, v)
 		}
+		prev = f
 	}
-	if a.Country != "" {
-		if compStr == "" {
-			compStr = a.Country
-		} else {
-			compStr = fmt.Sprintf("%s %s", compStr, a.Country)
+	return strings.Join(parts, ", ")
+}
+
+func (a *AddressQuery) field(f AddressField) string {
+	switch f {
+	case FIELD_PREMISE:
+		return strings.TrimSpace(fmt.Sprintf("%s %s", a.Subpremise, a.Premise))
+	case FIELD_STREET:
+		if a.Street != "" && a.Unit != "" {
+			return fmt.Sprintf("%s #%s", a.Street, a.Unit)
 		}
+		return a.Street
+	case FIELD_CITY:
+		return a.City
+	case FIELD_STATE:
+		return a.State
+	case FIELD_POSTAL_CODE:
+		return a.PostalCode
+	case FIELD_COUNTRY:
+		return a.Country
+	default:
+		return ""
 	}
-	return compStr
 }
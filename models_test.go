@@ -0,0 +1,131 @@
+package geocode
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestModels(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"split street unit, succeeds":   testSplitUnit,
+		"point key, succeeds":           testPointKey,
+		"address field order, succeeds": testAddressFieldOrder,
+		"address string, succeeds":      testAddressString,
+		"point validity, succeeds":      testPointIsValid,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testSplitUnit(t *testing.T) {
+	for street, want := range map[string][2]string{
+		"Unit 4, 123 Main St":    {"4", "123 Main St"},
+		"Apt 12B, 50 Elm Ave":    {"12B", "50 Elm Ave"},
+		"# 7, 1 Market St":       {"7", "1 Market St"},
+		"123 Main St, Suite 200": {"200", "123 Main St"},
+		"123 Main St Apt 4":      {"4", "123 Main St"},
+		"123 Main St":            {"", "123 Main St"},
+		"1 Unity Plaza":          {"", "1 Unity Plaza"},
+		"123 Main St Apt#4":      {"4", "123 Main St"},
+		"Apt4, 50 Elm Ave":       {"4", "50 Elm Ave"},
+		"1 Steiner":              {"", "1 Steiner"},
+		"500 Stevenson":          {"", "500 Stevenson"},
+		"120 Unitas":             {"", "120 Unitas"},
+		"Stevens, 5 Main St":     {"", "Stevens, 5 Main St"},
+	} {
+		a := &AddressQuery{Street: street}
+		a.splitUnit()
+		require.Equal(t, want[0], a.Unit, street)
+		require.Equal(t, want[1], a.Street, street)
+	}
+
+	a := &AddressQuery{Street: "Unit 4, 123 Main St", City: "Petaluma"}
+	a.splitUnit()
+	require.Equal(t, "123 Main St #4, Petaluma", a.addressString())
+}
+
+func testPointKey(t *testing.T) {
+	a := &Point{Latitude: 37.7749001, Longitude: -122.4194002}
+	b := &Point{Latitude: 37.7749004, Longitude: -122.4193998}
+	c := &Point{Latitude: 37.7751, Longitude: -122.4194}
+
+	require.Equal(t, "37.774900,-122.419400", a.Key(6))
+	require.Equal(t, a.Key(6), b.Key(6))
+	require.NotEqual(t, a.Key(6), c.Key(6))
+	require.Equal(t, a.Key(3), c.Key(3))
+	require.NotEqual(t, a.Key(4), c.Key(4))
+
+	require.Equal(t, "0.00,0.00", (&Point{Latitude: -0.0001, Longitude: 0.0001}).Key(2))
+}
+
+func testAddressFieldOrder(t *testing.T) {
+	a := &AddressQuery{
+		Street:     "1-1 Marunouchi",
+		City:       "Chiyoda",
+		State:      "Tokyo",
+		PostalCode: "100-0005",
+		Country:    "Japan",
+	}
+	require.Equal(t, "1-1 Marunouchi, Chiyoda, Tokyo 100-0005, Japan", a.formatAddress(nil))
+
+	reversed := []AddressField{FIELD_COUNTRY, FIELD_POSTAL_CODE, FIELD_STATE, FIELD_CITY, FIELD_STREET, FIELD_PREMISE}
+	require.Equal(t, "Japan, 100-0005, Tokyo, Chiyoda, 1-1 Marunouchi", a.formatAddress(reversed))
+
+	// omitted fields follow in their default order
+	require.Equal(t, "Japan, 1-1 Marunouchi, Chiyoda, Tokyo 100-0005", a.formatAddress([]AddressField{FIELD_COUNTRY}))
+}
+
+func testAddressString(t *testing.T) {
+	for _, tc := range []struct {
+		addr AddressQuery
+		want string
+	}{
+		{
+			addr: AddressQuery{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA", PostalCode: "94043", Country: "USA"},
+			want: "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA",
+		},
+		{addr: AddressQuery{PostalCode: "94043", Country: "USA"}, want: "94043, USA"},
+		{addr: AddressQuery{City: "Mountain View", State: "CA"}, want: "Mountain View, CA"},
+		{addr: AddressQuery{City: "Mountain View", PostalCode: "94043"}, want: "Mountain View, 94043"},
+		{addr: AddressQuery{State: "CA", PostalCode: "94043"}, want: "CA 94043"},
+		{addr: AddressQuery{Premise: "Building 40", Street: "1600 Amphitheatre Pkwy"}, want: "Building 40, 1600 Amphitheatre Pkwy"},
+		{addr: AddressQuery{}, want: ""},
+	} {
+		require.Equal(t, tc.want, tc.addr.addressString())
+	}
+}
+
+func testPointIsValid(t *testing.T) {
+	for _, p := range []Point{
+		{Latitude: 0, Longitude: 0},
+		{Latitude: 0, Longitude: 32.58},
+		{Latitude: 51.4779, Longitude: 0},
+		{Latitude: 90, Longitude: 180},
+		{Latitude: -90, Longitude: -180},
+	} {
+		require.True(t, p.IsValid(), "%v", p)
+	}
+
+	nan := math.NaN()
+	for _, p := range []Point{
+		{Latitude: 90.0001, Longitude: 0},
+		{Latitude: -91, Longitude: 10},
+		{Latitude: 10, Longitude: 180.5},
+		{Latitude: 10, Longitude: -181},
+		{Latitude: nan, Longitude: 10},
+		{Latitude: 10, Longitude: nan},
+	} {
+		require.False(t, p.IsValid(), "%v", p)
+	}
+	var unset *Point
+	require.False(t, unset.IsValid())
+
+	// distances across the equator and prime meridian
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+	d, err := gsc.GetDistance(context.Background(), KM, &Point{Latitude: 0, Longitude: -0.5}, &Point{Latitude: 0, Longitude: 0.5})
+	require.NoError(t, err)
+	require.InDelta(t, 111.2, d, 0.5)
+}
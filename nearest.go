@@ -0,0 +1,99 @@
+package geocode
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// NearestByTravelTime returns the facility with the shortest travel time from origin and that time,
+// unreachable facilities are skipped and ErrNoRoute is returned when none can be reached
+func (g *geoCodeService) NearestByTravelTime(ctx context.Context, origin *Point, facilities []*Point, mode TravelMode) (*Point, time.Duration, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, 0, ErrNilContext
+	}
+
+	if _, ok := mode.mapsMode(); !ok {
+		g.log(ctx).Error(ERR_INVALID_TRAVEL_MODE, zap.String("mode", string(mode)))
+		return nil, 0, ErrInvalidTravelMode
+	}
+
+	if origin == nil || !origin.IsValid() || len(facilities) < 1 {
+		return nil, 0, ErrInvalidGeoLatLng
+	}
+	destStrs := []string{}
+	for _, f := range facilities {
+		if f == nil || !f.IsValid() {
+			return nil, 0, ErrInvalidGeoLatLng
+		}
+		destStrs = append(destStrs, fmt.Sprintf("%.6f %.6f", f.Latitude, f.Longitude))
+	}
+
+	resp, err := g.backend().DistanceMatrix(ctx, &DistanceMatrixRequest{
+		Origins:      []string{fmt.Sprintf("%.6f %.6f", origin.Latitude, origin.Longitude)},
+		Destinations: destStrs,
+		Mode:         mode,
+	})
+	if err != nil {
+		g.log(ctx).Error("error getting route matrix", zap.Error(err), statusField(err))
+		return nil, 0, err
+	}
+	if resp == nil || len(resp.Rows) != 1 {
+		g.log(ctx).Error(ERR_EMPTY_RESPONSE)
+		return nil, 0, ErrEmptyResponse
+	}
+
+	best, bestDur := -1, time.Duration(0)
+	for j, elem := range resp.Rows[0] {
+		if j >= len(facilities) || elem.Status != STATUS_OK {
+			continue
+		}
+		if best < 0 || elem.Duration < bestDur {
+			best, bestDur = j, elem.Duration
+		}
+	}
+	if best < 0 {
+		g.log(ctx).Error(ERR_NO_ROUTE, zap.Int("facilities", len(facilities)))
+		return nil, 0, ErrNoRoute
+	}
+
+	return facilities[best], bestDur, nil
+}
+
+// SnapToKnown geocodes addr and returns the nearest of the known points within maxDistance, in unit u,
+// of the result, ErrGeoCodeNoResults is returned when none is close enough
+func (g *geoCodeService) SnapToKnown(ctx context.Context, addr *AddressQuery, known []*Point, maxDistance float64, u DistanceUnit) (*Point, error) {
+	if !u.isValid() {
+		return nil, ErrInvalidGeoUnit
+	}
+	for _, k := range known {
+		if k == nil || !k.IsValid() {
+			return nil, ErrInvalidGeoLatLng
+		}
+	}
+
+	pt, err := g.GeocodeAddress(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Point
+	bestDist := 0.0
+	for _, k := range known {
+		d, err := distance(g.DistanceCalculator, u, pt, k)
+		if err != nil {
+			return nil, err
+		}
+		if d <= maxDistance && (best == nil || d < bestDist) {
+			best, bestDist = k, d
+		}
+	}
+	if best == nil {
+		g.log(ctx).Error(NO_RESULTS, zap.Int("known", len(known)), zap.Float64("max_distance", maxDistance))
+		return nil, ErrGeoCodeNoResults
+	}
+	return best, nil
+}
@@ -0,0 +1,81 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestNearestByTravelTime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	elems := []*maps.DistanceMatrixElement{
+		{Status: STATUS_OK, Duration: 25 * time.Minute},
+		{Status: STATUS_ZERO_RESULTS},
+		{Status: STATUS_OK, Duration: 12 * time.Minute},
+		{Status: STATUS_OK, Duration: 18 * time.Minute},
+	}
+	c := &fakeMapsClient{
+		matrixFn: func(r *maps.DistanceMatrixRequest) (*maps.DistanceMatrixResponse, error) {
+			return &maps.DistanceMatrixResponse{
+				Rows: []maps.DistanceMatrixElementsRow{{Elements: elems}},
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	origin := &Point{Latitude: 37.7749, Longitude: -122.4194}
+	facilities := []*Point{
+		{Latitude: 37.7793, Longitude: -122.4192},
+		{Latitude: 37.8199, Longitude: -122.4783},
+		{Latitude: 37.8044, Longitude: -122.2712},
+		{Latitude: 37.6879, Longitude: -122.4702},
+	}
+
+	f, d, err := gsc.NearestByTravelTime(ctx, origin, facilities, WALKING)
+	require.NoError(t, err)
+	require.Equal(t, facilities[2], f)
+	require.Equal(t, 12*time.Minute, d)
+	require.Equal(t, maps.TravelModeWalking, c.matrixReqs[0].Mode)
+	require.Equal(t, 4, len(c.matrixReqs[0].Destinations))
+
+	elems = []*maps.DistanceMatrixElement{{Status: STATUS_ZERO_RESULTS}, nil}
+	_, _, err = gsc.NearestByTravelTime(ctx, origin, facilities[:2], DRIVING)
+	require.Equal(t, ErrNoRoute, err)
+
+	_, _, err = gsc.NearestByTravelTime(ctx, origin, facilities, TravelMode("TELEPORT"))
+	require.Equal(t, ErrInvalidTravelMode, err)
+}
+
+func TestSnapToKnown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(37.7790, -122.4190, "1 Dr Carlton B Goodlett Pl, San Francisco, CA 94102, USA", "street_address")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	stores := []*Point{
+		{Latitude: 37.8044, Longitude: -122.2712},
+		{Latitude: 37.7793, Longitude: -122.4192},
+		{Latitude: 37.7749, Longitude: -122.4194},
+	}
+	addr := &AddressQuery{Street: "1 Dr Carlton B Goodlett Pl", City: "San Francisco", State: "CA"}
+
+	pt, err := gsc.SnapToKnown(ctx, addr, stores, 1, KM)
+	require.NoError(t, err)
+	require.Equal(t, stores[1], pt)
+
+	_, err = gsc.SnapToKnown(ctx, addr, []*Point{stores[0]}, 1, KM)
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.SnapToKnown(ctx, addr, stores, 1, DistanceUnit("LEAGUES"))
+	require.Equal(t, ErrInvalidGeoUnit, err)
+}
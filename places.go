@@ -0,0 +1,186 @@
+package geocode
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap"
+	"googlemaps.github.io/maps"
+)
+
+// Place establishment found with the places api
+type Place struct {
+	PlaceID string   `json:"place_id"`
+	Name    string   `json:"name"`
+	Types   []string `json:"types"`
+	Point   *Point   `json:"point"`
+}
+
+// PlaceCandidate place matching a FindPlace query with its confidence
+type PlaceCandidate struct {
+	Place
+	// Confidence between 0 and 1, from the api's ranking and how much of the query the place's name and address match
+	Confidence float64 `json:"confidence"`
+}
+
+// FindPlace returns the places matching a text query, e.g. a name and city, by descending confidence
+func (g *geoCodeService) FindPlace(ctx context.Context, input string) ([]PlaceCandidate, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	resp, err := g.api(ctx, placesAPI).FindPlaceFromText(ctx, &maps.FindPlaceFromTextRequest{
+		Input:     input,
+		InputType: maps.FindPlaceFromTextInputTypeTextQuery,
+		Fields: []maps.PlaceSearchFieldMask{
+			maps.PlaceSearchFieldMaskPlaceID,
+			maps.PlaceSearchFieldMaskName,
+			maps.PlaceSearchFieldMaskFormattedAddress,
+			maps.PlaceSearchFieldMaskGeometry,
+			maps.PlaceSearchFieldMaskTypes,
+		},
+	})
+	if err != nil {
+		g.log(ctx).Error(ERROR_FINDING_PLACE, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrFindPlace)
+	}
+
+	if len(resp.Candidates) < 1 {
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS))
+		return nil, ErrGeoCodeNoResults
+	}
+
+	n := len(resp.Candidates)
+	candidates := []PlaceCandidate{}
+	for i, c := range resp.Candidates {
+		candidates = append(candidates, PlaceCandidate{
+			Place: Place{
+				PlaceID: c.PlaceID,
+				Name:    c.Name,
+				Types:   c.Types,
+				Point: &Point{
+					Latitude:         c.Geometry.Location.Lat,
+					Longitude:        c.Geometry.Location.Lng,
+					FormattedAddress: g.formatted(c.FormattedAddress),
+				},
+			},
+			// the api's rank weighs most, the query match breaks near ties
+			Confidence: 0.7*float64(n-i)/float64(n) + 0.3*queryMatch(input, c.Name+" "+c.FormattedAddress),
+		})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+	return candidates, nil
+}
+
+// queryMatch fraction of the query's words found in text
+func queryMatch(query, text string) float64 {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) < 1 {
+		return 0
+	}
+
+	text = strings.ToLower(text)
+	found := 0
+	for _, w := range words {
+		if strings.Contains(text, w) {
+			found++
+		}
+	}
+	return float64(found) / float64(len(words))
+}
+
+// FindOpenPlaces returns the places matching input that are open at the given time,
+// opening hours are local to the place so at should be in the place's time zone.
+// Places without opening hours, or whose details can't be fetched, are left out.
+func (g *geoCodeService) FindOpenPlaces(ctx context.Context, input string, at time.Time) ([]*Place, error) {
+	candidates, err := g.FindPlace(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+	places := make([]*Place, 0, len(candidates))
+	for i := range candidates {
+		places = append(places, &candidates[i].Place)
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, g.concurrency())
+	open := make([]bool, len(places))
+	for i, p := range places {
+		wg.Add(1)
+		go func(i int, p *Place) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			details, err := g.api(ctx, placesAPI).PlaceDetails(ctx, &maps.PlaceDetailsRequest{
+				PlaceID: p.PlaceID,
+				Fields:  []maps.PlaceDetailsFieldMask{maps.PlaceDetailsFieldMaskOpeningHours},
+			})
+			if err != nil {
+				g.log(ctx).Error(ERROR_FINDING_PLACE, zap.Error(err), statusField(err), zap.String("place_id", p.PlaceID))
+				return
+			}
+			open[i] = isOpenAt(details.OpeningHours, at)
+		}(i, p)
+	}
+	wg.Wait()
+
+	openPlaces := []*Place{}
+	for i, p := range places {
+		if open[i] {
+			openPlaces = append(openPlaces, p)
+		}
+	}
+	return openPlaces, nil
+}
+
+const minutesPerWeek = 7 * 24 * 60
+
+// isOpenAt checks the weekly opening periods against the weekday and clock time of at
+func isOpenAt(hours *maps.OpeningHours, at time.Time) bool {
+	if hours == nil || (hours.PermanentlyClosed != nil && *hours.PermanentlyClosed) {
+		return false
+	}
+
+	t := int(at.Weekday())*24*60 + at.Hour()*60 + at.Minute()
+	for _, p := range hours.Periods {
+		open, ok := weekMinute(p.Open)
+		if !ok {
+			continue
+		}
+		if p.Close.Time == "" {
+			// open around the clock
+			return true
+		}
+		closing, ok := weekMinute(p.Close)
+		if !ok {
+			continue
+		}
+		if closing <= open {
+			closing += minutesPerWeek
+		}
+		if (t >= open && t < closing) || (t+minutesPerWeek >= open && t+minutesPerWeek < closing) {
+			return true
+		}
+	}
+	return false
+}
+
+// weekMinute minutes since the start of the week for an "hhmm" opening time
+func weekMinute(oc maps.OpeningHoursOpenClose) (int, bool) {
+	hhmm, err := strconv.Atoi(oc.Time)
+	if err != nil || len(oc.Time) != 4 {
+		return 0, false
+	}
+	return int(oc.Day)*24*60 + (hhmm/100)*60 + hhmm%100, true
+}
@@ -0,0 +1,108 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestPlaces(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"open places filter, succeeds":      testFindOpenPlaces,
+		"opening hours periods, succeeds":   testIsOpenAt,
+		"place candidates ranked, succeeds": testFindPlaceConfidence,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func weekdayHours(open, close string) *maps.OpeningHours {
+	periods := []maps.OpeningHoursPeriod{}
+	for d := time.Monday; d <= time.Friday; d++ {
+		periods = append(periods, maps.OpeningHoursPeriod{
+			Open:  maps.OpeningHoursOpenClose{Day: d, Time: open},
+			Close: maps.OpeningHoursOpenClose{Day: d, Time: close},
+		})
+	}
+	return &maps.OpeningHours{Periods: periods}
+}
+
+func testFindOpenPlaces(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hours := map[string]*maps.OpeningHours{
+		"cafe":   weekdayHours("0700", "1500"),
+		"bakery": weekdayHours("0500", "1100"),
+	}
+	c := &fakeMapsClient{
+		findPlaceFn: func(r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+			return maps.FindPlaceFromTextResponse{Candidates: []maps.PlacesSearchResult{
+				{PlaceID: "cafe", Name: "Corner Cafe", FormattedAddress: "1 Main St, Petaluma, CA"},
+				{PlaceID: "bakery", Name: "Early Bakery", FormattedAddress: "2 Main St, Petaluma, CA"},
+			}}, nil
+		},
+		detailsFn: func(r *maps.PlaceDetailsRequest) (maps.PlaceDetailsResult, error) {
+			return maps.PlaceDetailsResult{PlaceID: r.PlaceID, OpeningHours: hours[r.PlaceID]}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	// a Wednesday afternoon
+	at := time.Date(2024, 5, 8, 13, 30, 0, 0, time.UTC)
+	places, err := gsc.FindOpenPlaces(ctx, "coffee Petaluma", at)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(places))
+	require.Equal(t, "Corner Cafe", places[0].Name)
+	require.Equal(t, "1 Main St, Petaluma, CA", places[0].Point.FormattedAddress)
+}
+
+func testIsOpenAt(t *testing.T) {
+	overnight := &maps.OpeningHours{Periods: []maps.OpeningHoursPeriod{{
+		Open:  maps.OpeningHoursOpenClose{Day: time.Saturday, Time: "2200"},
+		Close: maps.OpeningHoursOpenClose{Day: time.Sunday, Time: "0300"},
+	}}}
+	allDay := &maps.OpeningHours{Periods: []maps.OpeningHoursPeriod{{
+		Open: maps.OpeningHoursOpenClose{Day: time.Sunday, Time: "0000"},
+	}}}
+
+	sat := time.Date(2024, 5, 11, 23, 0, 0, 0, time.UTC)
+	sun := time.Date(2024, 5, 12, 2, 59, 0, 0, time.UTC)
+	require.True(t, isOpenAt(overnight, sat))
+	require.True(t, isOpenAt(overnight, sun))
+	require.False(t, isOpenAt(overnight, sun.Add(time.Minute)))
+	require.True(t, isOpenAt(allDay, sun))
+	require.False(t, isOpenAt(nil, sun))
+}
+
+func testFindPlaceConfidence(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		findPlaceFn: func(r *maps.FindPlaceFromTextRequest) (maps.FindPlaceFromTextResponse, error) {
+			return maps.FindPlaceFromTextResponse{Candidates: []maps.PlacesSearchResult{
+				{PlaceID: "roasters", Name: "Bean Roasters", FormattedAddress: "10 Oak St, Sebastopol, CA"},
+				{PlaceID: "cafe", Name: "Acme Coffee", FormattedAddress: "1 Main St, Petaluma, CA"},
+				{PlaceID: "deli", Name: "Main Street Deli", FormattedAddress: "3 Main St, Petaluma, CA"},
+			}}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	candidates, err := gsc.FindPlace(ctx, "Acme Coffee Petaluma")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(candidates))
+	// the full match outranks the api's top candidate, which matches none of the query
+	require.Equal(t, "cafe", candidates[0].PlaceID)
+	require.Equal(t, "roasters", candidates[1].PlaceID)
+	require.Equal(t, "deli", candidates[2].PlaceID)
+	for i := 1; i < len(candidates); i++ {
+		require.GreaterOrEqual(t, candidates[i-1].Confidence, candidates[i].Confidence)
+	}
+	require.LessOrEqual(t, candidates[0].Confidence, 1.0)
+	require.Greater(t, candidates[2].Confidence, 0.0)
+}
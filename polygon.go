@@ -0,0 +1,47 @@
+package geocode
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// GeocodeWithinPolygon geocodes addr and returns ErrGeoCodeNoResults
+// when the resolved point falls outside polygon
+func (g *geoCodeService) GeocodeWithinPolygon(ctx context.Context, addr *AddressQuery, polygon []*Point) (*Point, error) {
+	if len(polygon) < 3 {
+		g.log(ctx).Error(ERR_INVALID_POLYGON, zap.Int("vertices", len(polygon)))
+		return nil, ErrInvalidPolygon
+	}
+	for _, p := range polygon {
+		if p == nil {
+			g.log(ctx).Error(ERR_INVALID_POLYGON)
+			return nil, ErrInvalidPolygon
+		}
+	}
+
+	pt, err := g.GeocodeAddress(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	if !pointInPolygon(pt, polygon) {
+		g.log(ctx).Error(NO_RESULTS, zap.String("reason", "outside polygon"), zap.Float64("lat", pt.Latitude), zap.Float64("lng", pt.Longitude))
+		return nil, ErrGeoCodeNoResults
+	}
+	return pt, nil
+}
+
+// pointInPolygon ray casting test treating coordinates as planar,
+// the polygon is implicitly closed and must not cross the antimeridian
+func pointInPolygon(pt *Point, polygon []*Point) bool {
+	inside := false
+	for i, j := 0, len(polygon)-1; i < len(polygon); j, i = i, i+1 {
+		a, b := polygon[i], polygon[j]
+		if (a.Latitude > pt.Latitude) != (b.Latitude > pt.Latitude) &&
+			pt.Longitude < (b.Longitude-a.Longitude)*(pt.Latitude-a.Latitude)/(b.Latitude-a.Latitude)+a.Longitude {
+			inside = !inside
+		}
+	}
+	return inside
+}
@@ -0,0 +1,43 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodeWithinPolygon(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	results := map[string]maps.GeocodingResult{
+		"Golden Gate Park, San Francisco, USA": fakeResult(37.7694, -122.4862, "Golden Gate Park, San Francisco, CA, USA", "park"),
+		"Lake Merritt, Oakland, USA":           fakeResult(37.8024, -122.2579, "Lake Merritt, Oakland, CA, USA", "natural_feature"),
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{results[r.Address]}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	// rough outline of San Francisco
+	sf := []*Point{
+		{Latitude: 37.8120, Longitude: -122.5150},
+		{Latitude: 37.8120, Longitude: -122.3550},
+		{Latitude: 37.7080, Longitude: -122.3550},
+		{Latitude: 37.7080, Longitude: -122.5150},
+	}
+
+	pt, err := gsc.GeocodeWithinPolygon(ctx, &AddressQuery{Street: "Golden Gate Park", City: "San Francisco"}, sf)
+	require.NoError(t, err)
+	require.Equal(t, "Golden Gate Park, San Francisco, CA, USA", pt.FormattedAddress)
+
+	_, err = gsc.GeocodeWithinPolygon(ctx, &AddressQuery{Street: "Lake Merritt", City: "Oakland"}, sf)
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.GeocodeWithinPolygon(ctx, &AddressQuery{Street: "Lake Merritt", City: "Oakland"}, sf[:2])
+	require.Equal(t, ErrInvalidPolygon, err)
+}
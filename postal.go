@@ -0,0 +1,97 @@
+package geocode
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// PostalResult geocoded postal code with the place it resolves to
+type PostalResult struct {
+	Point
+	// Locality city, or postal town, the postal code belongs to
+	Locality string `json:"locality,omitempty"`
+	// AdminArea first level administrative area, e.g. the US state
+	AdminArea string `json:"admin_area,omitempty"`
+	// PostalCode as returned by the api, the requested code when not returned
+	PostalCode string `json:"postal_code"`
+}
+
+// GeocodePostalDetailed geocodes the postal code returning its locality and admin area along with the point
+func (g *geoCodeService) GeocodePostalDetailed(ctx context.Context, postalCode, countryCode string) (*PostalResult, error) {
+	pt, r, err := g.geocodePostal(ctx, postalCode, countryCode)
+	if err != nil {
+		return nil, err
+	}
+
+	pr := &PostalResult{
+		Point:      *pt,
+		PostalCode: strings.TrimSpace(postalCode),
+	}
+	if r == nil {
+		return pr, nil
+	}
+
+	for _, typ := range []string{"locality", "postal_town"} {
+		if name := componentName(r.AddressComponents, typ); name != "" {
+			pr.Locality = name
+			break
+		}
+	}
+	pr.AdminArea = componentName(r.AddressComponents, "administrative_area_level_1")
+	if code := componentName(r.AddressComponents, "postal_code"); code != "" {
+		pr.PostalCode = code
+	}
+	return pr, nil
+}
+
+// GeocodePostalAll geocodes a partial or ambiguous postal code returning every matching postal area,
+// in the api's order
+func (g *geoCodeService) GeocodePostalAll(ctx context.Context, partial, countryCode string) ([]*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	partial = strings.TrimSpace(partial)
+	if partial == "" {
+		return nil, ErrInvalidPostalCode
+	}
+	if countryCode == "" {
+		countryCode = "USA"
+	}
+
+	req := &GeocodeRequest{
+		Address: partial,
+		Components: map[string]string{
+			"country": countryCode,
+		},
+		Language: g.Language,
+		Region:   g.Region,
+	}
+	resp, err := g.backend().Geocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_POSTAL, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrGeoCodePostalCode)
+	}
+	g.audit("GeocodePostalAll", req, resp)
+	resp = usableResults(resp)
+	if g.DedupePlaceIDs {
+		resp = dedupeByPlaceID(resp)
+	}
+
+	postalTypes := []string{"postal_code", "postal_code_prefix"}
+	pts := []*Point{}
+	for _, r := range resp {
+		if typeRank(r, postalTypes) == len(postalTypes) {
+			continue
+		}
+		pts = append(pts, g.newPoint(r))
+	}
+	if len(pts) < 1 {
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS), zap.String("postalcode", partial))
+		return nil, ErrGeoCodeNoResults
+	}
+	return pts, nil
+}
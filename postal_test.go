@@ -0,0 +1,100 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodePostalDetailed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			require.Equal(t, "94952", r.Components[maps.ComponentPostalCode])
+			res := fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")
+			res.AddressComponents = []maps.AddressComponent{
+				{LongName: "94952", ShortName: "94952", Types: []string{"postal_code"}},
+				{LongName: "Petaluma", ShortName: "Petaluma", Types: []string{"locality", "political"}},
+				{LongName: "California", ShortName: "CA", Types: []string{"administrative_area_level_1", "political"}},
+			}
+			return []maps.GeocodingResult{res}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 8}, c)
+
+	for i := 0; i < 2; i++ {
+		pr, err := gsc.GeocodePostalDetailed(ctx, "94952", "")
+		require.NoError(t, err)
+		require.Equal(t, "Petaluma", pr.Locality)
+		require.Equal(t, "California", pr.AdminArea)
+		require.Equal(t, "94952", pr.PostalCode)
+		require.Equal(t, 38.24, pr.Latitude)
+		require.Equal(t, "Petaluma, CA 94952, USA", pr.FormattedAddress)
+	}
+	// second lookup is served from the cache
+	require.Equal(t, 1, c.geocodeCalls())
+}
+
+func TestGeocodePostalAll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			require.Equal(t, "9495", r.Address)
+			require.Equal(t, "USA", r.Components[maps.ComponentCountry])
+			return []maps.GeocodingResult{
+				fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code"),
+				fakeResult(38.27, -122.67, "Petaluma, CA 94954, USA", "postal_code"),
+				fakeResult(38.10, -122.57, "Novato, CA 94945, USA", "postal_code"),
+				fakeResult(38.23, -122.64, "Petaluma, CA, USA", "locality", "political"),
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	pts, err := gsc.GeocodePostalAll(ctx, "9495", "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(pts))
+	require.Equal(t, "Petaluma, CA 94952, USA", pts[0].FormattedAddress)
+	require.Equal(t, "Petaluma, CA 94954, USA", pts[1].FormattedAddress)
+	require.Equal(t, "Novato, CA 94945, USA", pts[2].FormattedAddress)
+	require.Equal(t, 38.10, pts[2].Latitude)
+
+	_, err = gsc.GeocodePostalAll(ctx, " ", "")
+	require.Equal(t, ErrInvalidPostalCode, err)
+}
+
+func TestDedupePlaceIDs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	result := func(placeID string, lt maps.GeocodeAccuracy, addr string) maps.GeocodingResult {
+		r := fakeResult(38.24, -122.64, addr, "postal_code")
+		r.PlaceID, r.Geometry.LocationType = placeID, string(lt)
+		return r
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{
+				result("p94952", maps.GeocodeAccuracyApproximate, "94952 approximate"),
+				result("p94954", maps.GeocodeAccuracyApproximate, "94954"),
+				result("p94952", maps.GeocodeAccuracyGeometricCenter, "94952 center"),
+			}, nil
+		},
+	}
+
+	pts, err := newFakeService(t, Config{}, c).GeocodePostalAll(ctx, "9495", "")
+	require.NoError(t, err)
+	require.Equal(t, 3, len(pts))
+
+	pts, err = newFakeService(t, Config{DedupePlaceIDs: true}, c).GeocodePostalAll(ctx, "9495", "")
+	require.NoError(t, err)
+	require.Equal(t, 2, len(pts))
+	require.Equal(t, "94952 center", pts[0].FormattedAddress)
+	require.Equal(t, "94954", pts[1].FormattedAddress)
+}
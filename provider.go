@@ -0,0 +1,318 @@
+package geocode
+
+import (
+	"context"
+	"time"
+
+	"googlemaps.github.io/maps"
+
+	"github.com/comfforts/errors"
+)
+
+// Provider geocoding and routing backend the service delegates to, in terms of the package's own types.
+// Places, timezone and elevation lookups aren't part of it and keep using google maps.
+type Provider interface {
+	Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error)
+	ReverseGeocode(ctx context.Context, r *ReverseGeocodeRequest) ([]Result, error)
+	Directions(ctx context.Context, r *DirectionsRequest) ([]*Route, error)
+	DistanceMatrix(ctx context.Context, r *DistanceMatrixRequest) (*RouteMatrix, error)
+}
+
+// GeocodeRequest forward geocoding request
+type GeocodeRequest struct {
+	Address string
+	// Components filters by address component, e.g. "country" or "postal_code"
+	Components map[string]string
+	Language   string
+	Region     string
+}
+
+// ReverseGeocodeRequest reverse geocoding request
+type ReverseGeocodeRequest struct {
+	LatLng LatLng
+	// ResultTypes and LocationTypes restrict the returned results, all when empty
+	ResultTypes   []string
+	LocationTypes []string
+	Language      string
+}
+
+// DirectionsRequest origin and destination are addresses or "lat lng" strings
+type DirectionsRequest struct {
+	Origin       string
+	Destination  string
+	Mode         TravelMode
+	Language     string
+	Region       string
+	Alternatives bool
+	Units        Units
+	// Avoid route features, "tolls", "highways" or "ferries"
+	Avoid []string
+	// DepartureTime and ArrivalTime, "now" or unix seconds, make the route traffic aware
+	DepartureTime string
+	ArrivalTime   string
+}
+
+// DistanceMatrixRequest origins and destinations are addresses or "lat lng" strings
+type DistanceMatrixRequest struct {
+	Origins      []string
+	Destinations []string
+	Mode         TravelMode
+	Units        Units
+}
+
+// RouteMatrix distance matrix response, a row per origin with an element per destination
+type RouteMatrix struct {
+	OriginAddresses      []string
+	DestinationAddresses []string
+	Rows                 [][]MatrixElement
+}
+
+// MatrixElement travel duration and distance in meters of an origin destination pair
+type MatrixElement struct {
+	Status       string
+	Duration     time.Duration
+	Distance     int
+	DistanceText string
+}
+
+// NewGoogleProvider returns the google maps Provider, the default when Config.Provider isn't set
+func NewGoogleProvider(cfg Config) (Provider, error) {
+	if cfg.GeocoderKey == "" {
+		return nil, errors.NewAppError(errors.ERROR_MISSING_REQUIRED)
+	}
+	c, err := newMapsClient(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
+	return googleProvider{c: c}, nil
+}
+
+// googleProvider adapts a maps client to Provider
+type googleProvider struct {
+	c mapsClient
+}
+
+func (p googleProvider) Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error) {
+	req := &maps.GeocodingRequest{
+		Address:  r.Address,
+		Language: r.Language,
+		Region:   r.Region,
+	}
+	if len(r.Components) > 0 {
+		req.Components = map[maps.Component]string{}
+		for k, v := range r.Components {
+			req.Components[maps.Component(k)] = v
+		}
+	}
+	resp, err := p.c.Geocode(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newGeocoderResults(resp).Results, nil
+}
+
+func (p googleProvider) ReverseGeocode(ctx context.Context, r *ReverseGeocodeRequest) ([]Result, error) {
+	req := &maps.GeocodingRequest{
+		LatLng:     &maps.LatLng{Lat: r.LatLng.Lat, Lng: r.LatLng.Lng},
+		ResultType: r.ResultTypes,
+		Language:   r.Language,
+	}
+	for _, t := range r.LocationTypes {
+		req.LocationType = append(req.LocationType, maps.GeocodeAccuracy(t))
+	}
+	resp, err := p.c.Geocode(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newGeocoderResults(resp).Results, nil
+}
+
+func (p googleProvider) Directions(ctx context.Context, r *DirectionsRequest) ([]*Route, error) {
+	req := &maps.DirectionsRequest{
+		Origin:        r.Origin,
+		Destination:   r.Destination,
+		Language:      r.Language,
+		Region:        r.Region,
+		Alternatives:  r.Alternatives,
+		DepartureTime: r.DepartureTime,
+		ArrivalTime:   r.ArrivalTime,
+	}
+	if r.Mode != "" {
+		m, ok := r.Mode.mapsMode()
+		if !ok {
+			return nil, ErrInvalidTravelMode
+		}
+		req.Mode = m
+	}
+	u, ok := r.Units.mapsUnits()
+	if !ok {
+		return nil, ErrInvalidUnits
+	}
+	req.Units = u
+	for _, a := range r.Avoid {
+		req.Avoid = append(req.Avoid, maps.Avoid(a))
+	}
+	routes, waypoints, err := p.c.Directions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return newRoutes(routes, waypoints), nil
+}
+
+func (p googleProvider) DistanceMatrix(ctx context.Context, r *DistanceMatrixRequest) (*RouteMatrix, error) {
+	req := &maps.DistanceMatrixRequest{
+		Origins:      r.Origins,
+		Destinations: r.Destinations,
+	}
+	if r.Mode != "" {
+		m, ok := r.Mode.mapsMode()
+		if !ok {
+			return nil, ErrInvalidTravelMode
+		}
+		req.Mode = m
+	}
+	u, ok := r.Units.mapsUnits()
+	if !ok {
+		return nil, ErrInvalidUnits
+	}
+	req.Units = u
+	resp, err := p.c.DistanceMatrix(ctx, req)
+	if err != nil || resp == nil {
+		return nil, err
+	}
+
+	m := &RouteMatrix{
+		OriginAddresses:      resp.OriginAddresses,
+		DestinationAddresses: resp.DestinationAddresses,
+		Rows:                 [][]MatrixElement{},
+	}
+	for _, row := range resp.Rows {
+		elems := []MatrixElement{}
+		for _, e := range row.Elements {
+			if e == nil {
+				elems = append(elems, MatrixElement{Status: STATUS_ZERO_RESULTS})
+				continue
+			}
+			elems = append(elems, MatrixElement{
+				Status:       e.Status,
+				Duration:     e.Duration,
+				Distance:     e.Distance.Meters,
+				DistanceText: e.Distance.HumanReadable,
+			})
+		}
+		m.Rows = append(m.Rows, elems)
+	}
+	return m, nil
+}
+
+// newRoutes converts directions api routes, leg i runs from waypoint i to i+1
+func newRoutes(routes []maps.Route, waypoints []maps.GeocodedWaypoint) []*Route {
+	rts := []*Route{}
+	for _, rt := range routes {
+		route := &Route{
+			Summary:  rt.Summary,
+			Legs:     []*RouteLeg{},
+			Polyline: rt.OverviewPolyline.Points,
+		}
+		for i, l := range rt.Legs {
+			if l == nil {
+				continue
+			}
+			steps := []*RouteStep{}
+			for _, st := range l.Steps {
+				if st == nil {
+					continue
+				}
+				steps = append(steps, &RouteStep{
+					Instructions: st.HTMLInstructions,
+					Duration:     st.Duration,
+					Distance:     st.Distance.Meters,
+					Polyline:     decodePath(st.Polyline.Points),
+				})
+			}
+			route.Legs = append(route.Legs, &RouteLeg{
+				Start:         l.StartAddress,
+				End:           l.EndAddress,
+				Duration:      l.Duration,
+				Distance:      l.Distance.Meters,
+				DistanceText:  l.Distance.HumanReadable,
+				Steps:         steps,
+				Warnings:      append([]string{}, rt.Warnings...),
+				StartLocation: LatLng{Lat: l.StartLocation.Lat, Lng: l.StartLocation.Lng},
+				EndLocation:   LatLng{Lat: l.EndLocation.Lat, Lng: l.EndLocation.Lng},
+				StartTypes:    waypointTypes(waypoints, i),
+				EndTypes:      waypointTypes(waypoints, i+1),
+			})
+			route.Duration += l.Duration
+			route.Distance += l.Distance.Meters
+		}
+		rts = append(rts, route)
+	}
+	return rts
+}
+
+// decodePath decodes an encoded polyline, nil when it's missing or malformed
+func decodePath(encoded string) []LatLng {
+	if encoded == "" {
+		return nil
+	}
+	pts, err := maps.DecodePolyline(encoded)
+	if err != nil {
+		return nil
+	}
+	path := make([]LatLng, 0, len(pts))
+	for _, p := range pts {
+		path = append(path, LatLng{Lat: p.Lat, Lng: p.Lng})
+	}
+	return path
+}
+
+// waypointTypes place types of the i-th geocoded waypoint, leg i runs from waypoint i to i+1
+func waypointTypes(waypoints []maps.GeocodedWaypoint, i int) []string {
+	if i >= len(waypoints) {
+		return nil
+	}
+	return append([]string{}, waypoints[i].Types...)
+}
+
+func newGeocoderResults(resp []maps.GeocodingResult) *GeocoderResults {
+	results := &GeocoderResults{
+		Results: []Result{},
+		Status:  STATUS_OK,
+	}
+	if len(resp) < 1 {
+		results.Status = STATUS_ZERO_RESULTS
+	}
+
+	for _, r := range resp {
+		comps := []Address{}
+		for _, c := range r.AddressComponents {
+			comps = append(comps, Address{
+				LongName:  c.LongName,
+				ShortName: c.ShortName,
+				Types:     c.Types,
+			})
+		}
+		results.Results = append(results.Results, Result{
+			AddressComponents: comps,
+			FormattedAddress:  r.FormattedAddress,
+			Geometry: Geometry{
+				Bounds:       newBounds(r.Geometry.Bounds),
+				Location:     LatLng{Lat: r.Geometry.Location.Lat, Lng: r.Geometry.Location.Lng},
+				LocationType: r.Geometry.LocationType,
+				Viewport:     newBounds(r.Geometry.Viewport),
+			},
+			PlaceId:      r.PlaceID,
+			Types:        r.Types,
+			PartialMatch: r.PartialMatch,
+		})
+	}
+	return results
+}
+
+func newBounds(b maps.LatLngBounds) Bounds {
+	return Bounds{
+		Northeast: LatLng{Lat: b.NorthEast.Lat, Lng: b.NorthEast.Lng},
+		Southwest: LatLng{Lat: b.SouthWest.Lat, Lng: b.SouthWest.Lng},
+	}
+}
@@ -0,0 +1,193 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+type fakeProvider struct {
+	geocodeFn    func(r *GeocodeRequest) ([]Result, error)
+	reverseFn    func(r *ReverseGeocodeRequest) ([]Result, error)
+	directionsFn func(r *DirectionsRequest) ([]*Route, error)
+	matrixFn     func(r *DistanceMatrixRequest) (*RouteMatrix, error)
+}
+
+func (p *fakeProvider) Geocode(ctx context.Context, r *GeocodeRequest) ([]Result, error) {
+	return p.geocodeFn(r)
+}
+
+func (p *fakeProvider) ReverseGeocode(ctx context.Context, r *ReverseGeocodeRequest) ([]Result, error) {
+	return p.reverseFn(r)
+}
+
+func (p *fakeProvider) Directions(ctx context.Context, r *DirectionsRequest) ([]*Route, error) {
+	return p.directionsFn(r)
+}
+
+func (p *fakeProvider) DistanceMatrix(ctx context.Context, r *DistanceMatrixRequest) (*RouteMatrix, error) {
+	return p.matrixFn(r)
+}
+
+func TestProvider(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"service delegates to the configured provider, succeeds":    testProviderDelegation,
+		"apis outside of the provider, fail":                        testProviderUnsupported,
+		"google provider round trips through the service, succeeds": testGoogleProviderRoundTrip,
+	} {
+		t.Run(scenario, func(t *testing.T) {
+			fn(t)
+		})
+	}
+}
+
+func testProviderDelegation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var geocodeReq *GeocodeRequest
+	var directionsReq *DirectionsRequest
+	p := &fakeProvider{
+		geocodeFn: func(r *GeocodeRequest) ([]Result, error) {
+			geocodeReq = r
+			return []Result{{
+				FormattedAddress: "Petaluma, CA 94952, USA",
+				Geometry:         Geometry{Location: LatLng{Lat: 38.24, Lng: -122.64}},
+				Types:            []string{"locality"},
+			}}, nil
+		},
+		reverseFn: func(r *ReverseGeocodeRequest) ([]Result, error) {
+			return []Result{{
+				AddressComponents: []Address{{LongName: "Kentucky St", Types: []string{"route"}}},
+				FormattedAddress:  "Kentucky St, Petaluma, CA 94952, USA",
+				Geometry:          Geometry{Location: r.LatLng},
+				Types:             []string{"route"},
+			}}, nil
+		},
+		directionsFn: func(r *DirectionsRequest) ([]*Route, error) {
+			directionsReq = r
+			return []*Route{{
+				Summary: "US-101 S",
+				Legs: []*RouteLeg{{
+					Start:      "Petaluma, CA",
+					End:        "Novato, CA",
+					Duration:   15 * time.Minute,
+					Distance:   19000,
+					Warnings:   []string{"tolls ahead"},
+					StartTypes: []string{"locality"},
+					EndTypes:   []string{"locality"},
+					Steps: []*RouteStep{{
+						Instructions: "Head south",
+						Duration:     15 * time.Minute,
+						Distance:     19000,
+						Polyline:     []LatLng{{Lat: 38.24, Lng: -122.64}, {Lat: 38.1, Lng: -122.57}},
+					}},
+				}},
+			}}, nil
+		},
+		matrixFn: func(r *DistanceMatrixRequest) (*RouteMatrix, error) {
+			return &RouteMatrix{
+				OriginAddresses:      r.Origins,
+				DestinationAddresses: r.Destinations,
+				Rows: [][]MatrixElement{{
+					{Status: STATUS_OK, Duration: 15 * time.Minute, Distance: 19000},
+				}},
+			}, nil
+		},
+	}
+
+	gsc, err := NewGeoCodeService(Config{
+		Provider:  p,
+		AppLogger: logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA", Country: "US"})
+	require.NoError(t, err)
+	require.Equal(t, 38.24, pt.Latitude)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Contains(t, geocodeReq.Address, "Petaluma")
+
+	name, err := gsc.NearestRoadName(ctx, &Point{Latitude: 38.24, Longitude: -122.64})
+	require.NoError(t, err)
+	require.Equal(t, "Kentucky St", name)
+
+	legs, err := gsc.GetRouteForLatLongWithOptions(ctx, &Point{Latitude: 38.24, Longitude: -122.64}, &Point{Latitude: 38.1, Longitude: -122.57}, &RouteOptions{Language: "fr"})
+	require.NoError(t, err)
+	require.Equal(t, "fr", directionsReq.Language)
+	require.Equal(t, 1, len(legs))
+	require.Equal(t, 19000, legs[0].Distance)
+	require.Equal(t, []string{"tolls ahead"}, legs[0].Warnings)
+	require.Equal(t, []string{"locality"}, legs[0].EndTypes)
+	require.Equal(t, 2, len(legs[0].Steps[0].Polyline))
+	require.InDelta(t, 38.1, legs[0].Steps[0].Polyline[1].Lat, 1e-5)
+
+	legs, err = gsc.GetRouteMatrixForLatLong(ctx, []*Point{{Latitude: 38.24, Longitude: -122.64}}, []*Point{{Latitude: 38.1, Longitude: -122.57}})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(legs))
+	require.Equal(t, 15*time.Minute, legs[0].Duration)
+
+	require.NotEqual(t, "google", gsc.EffectiveConfig().Provider)
+}
+
+func testProviderUnsupported(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	_, err := NewGeoCodeService(Config{AppLogger: logger.NewTestAppLogger(t.TempDir())})
+	require.Error(t, err)
+
+	gsc, err := NewGeoCodeService(Config{
+		Provider:  &fakeProvider{},
+		AppLogger: logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+
+	_, err = gsc.api(ctx, placesAPI).FindPlaceFromText(ctx, &maps.FindPlaceFromTextRequest{Input: "coffee"})
+	require.Equal(t, ErrUnsupportedByProvider, err)
+	_, err = gsc.api(ctx, timezoneAPI).Timezone(ctx, &maps.TimezoneRequest{})
+	require.Equal(t, ErrUnsupportedByProvider, err)
+}
+
+func testGoogleProviderRoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc, err := NewGeoCodeService(Config{
+		GeocoderKey: "test-key",
+		AppLogger:   logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+	require.IsType(t, googleProvider{}, gsc.provider)
+
+	c := &fakeMapsClient{
+		directionsFn: func(r *maps.DirectionsRequest) ([]maps.Route, []maps.GeocodedWaypoint, error) {
+			return []maps.Route{{
+				Summary:  "US-101 S",
+				Warnings: []string{"tolls ahead"},
+				Legs: []*maps.Leg{{
+					StartAddress: "Petaluma, CA",
+					EndAddress:   "Novato, CA",
+					Duration:     15 * time.Minute,
+					Distance:     maps.Distance{Meters: 19000},
+				}},
+			}}, []maps.GeocodedWaypoint{{Types: []string{"street_address"}}, {Types: []string{"locality"}}}, nil
+		},
+	}
+	gsc = newFakeService(t, Config{}, c)
+
+	origin, dest := &Point{Latitude: 38.24, Longitude: -122.64}, &Point{Latitude: 38.1, Longitude: -122.57}
+	routes, err := gsc.GetRoutesForLatLongWithOptions(ctx, origin, dest, &RouteOptions{Mode: WALKING})
+	require.NoError(t, err)
+	require.Equal(t, maps.TravelModeWalking, c.routeReqs[0].Mode)
+	require.Equal(t, 1, len(routes))
+	require.Equal(t, "US-101 S", routes[0].Summary)
+	require.Equal(t, 19000, routes[0].Distance)
+	require.Equal(t, []string{"tolls ahead"}, routes[0].Legs[0].Warnings)
+	require.Equal(t, []string{"street_address"}, routes[0].Legs[0].StartTypes)
+	require.Equal(t, []string{"locality"}, routes[0].Legs[0].EndTypes)
+}
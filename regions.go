@@ -0,0 +1,50 @@
+package geocode
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// adminRegions bundled first level subdivisions by ISO 3166-1 alpha-2 country code
+var adminRegions = map[string][]string{
+	"US": {
+		"Alabama", "Alaska", "Arizona", "Arkansas", "California", "Colorado", "Connecticut",
+		"Delaware", "District of Columbia", "Florida", "Georgia", "Hawaii", "Idaho", "Illinois",
+		"Indiana", "Iowa", "Kansas", "Kentucky", "Louisiana", "Maine", "Maryland", "Massachusetts",
+		"Michigan", "Minnesota", "Mississippi", "Missouri", "Montana", "Nebraska", "Nevada",
+		"New Hampshire", "New Jersey", "New Mexico", "New York", "North Carolina", "North Dakota",
+		"Ohio", "Oklahoma", "Oregon", "Pennsylvania", "Rhode Island", "South Carolina",
+		"South Dakota", "Tennessee", "Texas", "Utah", "Vermont", "Virginia", "Washington",
+		"West Virginia", "Wisconsin", "Wyoming",
+	},
+	"CA": {
+		"Alberta", "British Columbia", "Manitoba", "New Brunswick", "Newfoundland and Labrador",
+		"Northwest Territories", "Nova Scotia", "Nunavut", "Ontario", "Prince Edward Island",
+		"Quebec", "Saskatchewan", "Yukon",
+	},
+	"AU": {
+		"Australian Capital Territory", "New South Wales", "Northern Territory", "Queensland",
+		"South Australia", "Tasmania", "Victoria", "Western Australia",
+	},
+	"DE": {
+		"Baden-Württemberg", "Bavaria", "Berlin", "Brandenburg", "Bremen", "Hamburg", "Hesse",
+		"Lower Saxony", "Mecklenburg-Vorpommern", "North Rhine-Westphalia", "Rhineland-Palatinate",
+		"Saarland", "Saxony", "Saxony-Anhalt", "Schleswig-Holstein", "Thuringia",
+	},
+	"GB": {
+		"England", "Northern Ireland", "Scotland", "Wales",
+	},
+}
+
+// ListAdminRegions returns the first level administrative subdivisions of country
+// from a bundled dataset, countries without bundled data return ErrUnsupportedCountry
+func (g *geoCodeService) ListAdminRegions(ctx context.Context, country string) ([]string, error) {
+	regions, ok := adminRegions[countryCode(country)]
+	if !ok {
+		g.log(ctx).Error(ERR_UNSUPPORTED_COUNTRY, zap.String("country", country))
+		return nil, ErrUnsupportedCountry
+	}
+
+	return append([]string{}, regions...), nil
+}
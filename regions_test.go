@@ -0,0 +1,28 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAdminRegions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	gsc := newFakeService(t, Config{}, &fakeMapsClient{})
+
+	regions, err := gsc.ListAdminRegions(ctx, "USA")
+	require.NoError(t, err)
+	require.Equal(t, 51, len(regions))
+	require.Contains(t, regions, "California")
+
+	regions[0] = "Changed"
+	regions, err = gsc.ListAdminRegions(ctx, "US")
+	require.NoError(t, err)
+	require.Equal(t, "Alabama", regions[0])
+
+	_, err = gsc.ListAdminRegions(ctx, "Atlantis")
+	require.Equal(t, ErrUnsupportedCountry, err)
+}
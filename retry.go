@@ -0,0 +1,210 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"net"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// operation a maps api call, only idempotent operations are retried
+type operation struct {
+	name       string
+	idempotent bool
+}
+
+// the maps apis used are all reads, a mutating endpoint added later must not be flagged idempotent,
+// testOperationsFlagged fails for operations without an explicit, reviewed flag
+var (
+	opGeocode        = operation{name: "geocode", idempotent: true}
+	opDirections     = operation{name: "directions", idempotent: true}
+	opDistanceMatrix = operation{name: "distance_matrix", idempotent: true}
+	opTimezone       = operation{name: "timezone", idempotent: true}
+	opElevation      = operation{name: "elevation", idempotent: true}
+	opFindPlace      = operation{name: "find_place", idempotent: true}
+	opPlaceDetails   = operation{name: "place_details", idempotent: true}
+)
+
+// retryableStatuses google response statuses worth retrying, other statuses are permanent failures
+var retryableStatuses = map[string]bool{
+	"OVER_QUERY_LIMIT": true,
+	"UNKNOWN_ERROR":    true,
+}
+
+// retryable reports whether err is known to be transient, google's retryable statuses, 5xx responses
+// and network timeouts, any other error is treated as permanent
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	if retryableStatuses[responseStatus(err)] {
+		return true
+	}
+	var se *serverError
+	if errors.As(err, &se) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// retryPolicy retries failed calls up to retries more times, waiting an exponentially growing,
+// jittered backoff between attempts
+type retryPolicy struct {
+	retries int
+	backoff time.Duration
+	clock   Clock
+}
+
+// do calls fn, retrying idempotent operations while they fail with a retryable error,
+// non idempotent operations are called once. The context's error is returned when it ends a backoff.
+func (p retryPolicy) do(ctx context.Context, op operation, fn func() error) error {
+	attempts := 1
+	if op.idempotent && p.retries > 0 {
+		attempts += p.retries
+	}
+
+	var err error
+	for i := 0; i < attempts; i++ {
+		if i > 0 {
+			if werr := p.wait(ctx, i); werr != nil {
+				return werr
+			}
+		}
+		if err = fn(); err == nil {
+			return nil
+		}
+		if !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// wait sleeps before the attempt-th retry, half the backoff fixed and half random, returning early
+// with the context's error when it's done
+func (p retryPolicy) wait(ctx context.Context, attempt int) error {
+	if p.backoff <= 0 {
+		return ctx.Err()
+	}
+	d := p.backoff << (attempt - 1)
+	if d <= 0 || d > MAX_RETRY_BACKOFF {
+		// overflowed or past the cap
+		d = MAX_RETRY_BACKOFF
+	}
+	d = d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+
+	clock := p.clock
+	if clock == nil {
+		clock = realClock{}
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-clock.After(d):
+		return nil
+	}
+}
+
+// retryProvider retries the failed calls of the wrapped provider
+type retryProvider struct {
+	p      Provider
+	policy retryPolicy
+}
+
+func (r retryProvider) Geocode(ctx context.Context, req *GeocodeRequest) (res []Result, err error) {
+	err = r.policy.do(ctx, opGeocode, func() error {
+		res, err = r.p.Geocode(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryProvider) ReverseGeocode(ctx context.Context, req *ReverseGeocodeRequest) (res []Result, err error) {
+	err = r.policy.do(ctx, opGeocode, func() error {
+		res, err = r.p.ReverseGeocode(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryProvider) Directions(ctx context.Context, req *DirectionsRequest) (rts []*Route, err error) {
+	err = r.policy.do(ctx, opDirections, func() error {
+		rts, err = r.p.Directions(ctx, req)
+		return err
+	})
+	return rts, err
+}
+
+func (r retryProvider) DistanceMatrix(ctx context.Context, req *DistanceMatrixRequest) (res *RouteMatrix, err error) {
+	err = r.policy.do(ctx, opDistanceMatrix, func() error {
+		res, err = r.p.DistanceMatrix(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+// retryClient retries the failed calls of the wrapped subclient
+type retryClient struct {
+	c      mapsClient
+	policy retryPolicy
+}
+
+func (r retryClient) Geocode(ctx context.Context, req *maps.GeocodingRequest) (res []maps.GeocodingResult, err error) {
+	err = r.policy.do(ctx, opGeocode, func() error {
+		res, err = r.c.Geocode(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryClient) Directions(ctx context.Context, req *maps.DirectionsRequest) (rts []maps.Route, wps []maps.GeocodedWaypoint, err error) {
+	err = r.policy.do(ctx, opDirections, func() error {
+		rts, wps, err = r.c.Directions(ctx, req)
+		return err
+	})
+	return rts, wps, err
+}
+
+func (r retryClient) DistanceMatrix(ctx context.Context, req *maps.DistanceMatrixRequest) (res *maps.DistanceMatrixResponse, err error) {
+	err = r.policy.do(ctx, opDistanceMatrix, func() error {
+		res, err = r.c.DistanceMatrix(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryClient) Timezone(ctx context.Context, req *maps.TimezoneRequest) (res *maps.TimezoneResult, err error) {
+	err = r.policy.do(ctx, opTimezone, func() error {
+		res, err = r.c.Timezone(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryClient) Elevation(ctx context.Context, req *maps.ElevationRequest) (res []maps.ElevationResult, err error) {
+	err = r.policy.do(ctx, opElevation, func() error {
+		res, err = r.c.Elevation(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryClient) FindPlaceFromText(ctx context.Context, req *maps.FindPlaceFromTextRequest) (res maps.FindPlaceFromTextResponse, err error) {
+	err = r.policy.do(ctx, opFindPlace, func() error {
+		res, err = r.c.FindPlaceFromText(ctx, req)
+		return err
+	})
+	return res, err
+}
+
+func (r retryClient) PlaceDetails(ctx context.Context, req *maps.PlaceDetailsRequest) (res maps.PlaceDetailsResult, err error) {
+	err = r.policy.do(ctx, opPlaceDetails, func() error {
+		res, err = r.c.PlaceDetails(ctx, req)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,237 @@
+package geocode
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestRetry(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"retry idempotent calls, succeeds":      testRetry,
+		"retry with backoff, succeeds":          testRetryBackoff,
+		"permanent and cancelled errors, fails": testRetryStops,
+		"only transient errors retryable":       testRetryable,
+		"operations flag idempotency":           testOperationsFlagged,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+// testOperationsFlagged fails when an operation is declared without an explicit idempotent flag,
+// or one not reviewed here, so a mutating endpoint can't be retried by accident
+func testOperationsFlagged(t *testing.T) {
+	reviewed := map[string]bool{
+		"geocode":         true,
+		"directions":      true,
+		"distance_matrix": true,
+		"timezone":        true,
+		"elevation":       true,
+		"find_place":      true,
+		"place_details":   true,
+	}
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	require.NoError(t, err)
+
+	declared := map[string]bool{}
+	for _, f := range pkgs["geocode"].Files {
+		ast.Inspect(f, func(n ast.Node) bool {
+			lit, ok := n.(*ast.CompositeLit)
+			if !ok {
+				return true
+			}
+			if id, ok := lit.Type.(*ast.Ident); !ok || id.Name != "operation" {
+				return true
+			}
+			fields := map[string]string{}
+			for _, elt := range lit.Elts {
+				kv, ok := elt.(*ast.KeyValueExpr)
+				require.True(t, ok, "operation at %s needs keyed fields", fset.Position(lit.Pos()))
+				key := kv.Key.(*ast.Ident).Name
+				switch v := kv.Value.(type) {
+				case *ast.BasicLit:
+					fields[key], _ = strconv.Unquote(v.Value)
+				case *ast.Ident:
+					fields[key] = v.Name
+				}
+			}
+			pos := fset.Position(lit.Pos())
+			flag, ok := fields["idempotent"]
+			require.True(t, ok, "operation %q at %s doesn't set idempotent", fields["name"], pos)
+			want, ok := reviewed[fields["name"]]
+			require.True(t, ok, "operation %q at %s isn't reviewed for idempotency", fields["name"], pos)
+			require.Equal(t, strconv.FormatBool(want), flag, "operation %q at %s", fields["name"], pos)
+			declared[fields["name"]] = true
+			return true
+		})
+	}
+	require.Equal(t, len(reviewed), len(declared))
+}
+
+func testRetry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errTransient := &serverError{statusCode: http.StatusServiceUnavailable}
+
+	calls := 0
+	err := retryPolicy{retries: 2}.do(ctx, operation{name: "mutate"}, func() error {
+		calls++
+		return errTransient
+	})
+	require.Equal(t, errTransient, err)
+	require.Equal(t, 1, calls)
+
+	calls = 0
+	err = retryPolicy{retries: 2}.do(ctx, opGeocode, func() error {
+		calls++
+		return errTransient
+	})
+	require.Equal(t, errTransient, err)
+	require.Equal(t, 3, calls)
+
+	failures := 1
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			if failures > 0 {
+				failures--
+				return nil, errTransient
+			}
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Petaluma, CA, USA", "locality")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{MaxRetries: 2}, c)
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA, USA", pt.FormattedAddress)
+	require.Equal(t, 2, c.geocodeCalls())
+
+	failures = 1
+	gsc = newFakeService(t, Config{}, c)
+	_, err = gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.Equal(t, ErrGeoCodeAddress, err)
+	require.Equal(t, 3, c.geocodeCalls())
+}
+
+func testRetryBackoff(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	failures := 2
+	p := &fakeProvider{
+		geocodeFn: func(r *GeocodeRequest) ([]Result, error) {
+			if failures > 0 {
+				failures--
+				return nil, fmt.Errorf("maps: OVER_QUERY_LIMIT - You have exceeded your rate-limit for this API.")
+			}
+			return []Result{{
+				FormattedAddress: "Petaluma, CA, USA",
+				Geometry:         Geometry{Location: LatLng{Lat: 38.24, Lng: -122.64}},
+			}}, nil
+		},
+	}
+	clock := newFakeClock()
+	start := clock.Now()
+	gsc, err := NewGeoCodeService(Config{
+		Provider:     p,
+		MaxRetries:   2,
+		RetryBackoff: time.Second,
+		Clock:        clock,
+		AppLogger:    logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA, USA", pt.FormattedAddress)
+	require.Equal(t, 0, failures)
+
+	// waits of 1s then 2s, each at least half jittered
+	waited := clock.Now().Sub(start)
+	require.GreaterOrEqual(t, waited, 1500*time.Millisecond)
+	require.LessOrEqual(t, waited, 3*time.Second)
+	require.Equal(t, time.Second, gsc.EffectiveConfig().RetryBackoff)
+}
+
+// cancelClock Clock cancelling the context when waited on, its timers never fire
+type cancelClock struct {
+	realClock
+	cancel context.CancelFunc
+}
+
+func (c cancelClock) After(d time.Duration) <-chan time.Time {
+	c.cancel()
+	return make(chan time.Time)
+}
+
+func testRetryable(t *testing.T) {
+	for err, want := range map[error]bool{
+		fmt.Errorf("maps: OVER_QUERY_LIMIT - "):                                     true,
+		fmt.Errorf("maps: UNKNOWN_ERROR - "):                                        true,
+		&serverError{statusCode: http.StatusBadGateway}:                             true,
+		&url.Error{Op: "Get", Err: &serverError{statusCode: http.StatusBadGateway}}: true,
+		&net.DNSError{Err: "i/o timeout", IsTimeout: true}:                          true,
+		fmt.Errorf("maps: REQUEST_DENIED - "):                                       false,
+		fmt.Errorf("maps: INVALID_REQUEST - "):                                      false,
+		&net.DNSError{Err: "no such host", IsNotFound: true}:                        false,
+		&json.SyntaxError{}:                                                         false,
+		fmt.Errorf("unexpected failure"):                                            false,
+		ErrOffline:                                                                  false,
+		context.DeadlineExceeded:                                                    false,
+		context.Canceled:                                                            false,
+	} {
+		require.Equal(t, want, retryable(err), err.Error())
+	}
+}
+
+func testRetryStops(t *testing.T) {
+	calls := 0
+	errDenied := fmt.Errorf("maps: REQUEST_DENIED - The provided API key is invalid.")
+	err := retryPolicy{retries: 3}.do(context.Background(), opGeocode, func() error {
+		calls++
+		return errDenied
+	})
+	require.Equal(t, errDenied, err)
+	require.Equal(t, 1, calls)
+
+	// cancelled during the backoff
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	errLimit := fmt.Errorf("maps: OVER_QUERY_LIMIT - ")
+	err = retryPolicy{retries: 3, backoff: time.Hour, clock: cancelClock{cancel: cancel}}.do(ctx, opGeocode, func() error {
+		calls++
+		return errLimit
+	})
+	require.Equal(t, context.Canceled, err)
+	require.Equal(t, 1, calls)
+
+	// the deadline passes during the backoff
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	calls = 0
+	err = retryPolicy{retries: 3, backoff: time.Hour}.do(ctx, opGeocode, func() error {
+		calls++
+		return errLimit
+	})
+	require.Equal(t, context.DeadlineExceeded, err)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,94 @@
+package geocode
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+	"googlemaps.github.io/maps"
+)
+
+// Granularity level of detail of a reverse geocoded address
+type Granularity string
+
+const (
+	ADDRESS      Granularity = "ADDRESS"
+	STREET       Granularity = "STREET"
+	NEIGHBORHOOD Granularity = "NEIGHBORHOOD"
+	CITY         Granularity = "CITY"
+	COUNTY       Granularity = "COUNTY"
+	STATE        Granularity = "STATE"
+	COUNTRY      Granularity = "COUNTRY"
+)
+
+// filters returns the result and location type filters of the granularity
+func (gr Granularity) filters() ([]string, []string, bool) {
+	switch gr {
+	case ADDRESS:
+		return []string{"street_address", "premise"},
+			[]string{string(maps.GeocodeAccuracyRooftop), string(maps.GeocodeAccuracyRangeInterpolated)}, true
+	case STREET:
+		return []string{"route"}, nil, true
+	case NEIGHBORHOOD:
+		return []string{"neighborhood"}, nil, true
+	case CITY:
+		return []string{"locality"}, nil, true
+	case COUNTY:
+		return []string{"administrative_area_level_2"}, nil, true
+	case STATE:
+		return []string{"administrative_area_level_1"}, nil, true
+	case COUNTRY:
+		return []string{"country"}, nil, true
+	default:
+		return nil, nil, false
+	}
+}
+
+// ReverseGeocode returns the address of p at the requested granularity
+func (g *geoCodeService) ReverseGeocode(ctx context.Context, p *Point, gr Granularity) (*Point, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, ErrNilContext
+	}
+
+	if p == nil || !p.IsValid() {
+		return nil, ErrInvalidGeoLatLng
+	}
+	resultTypes, locationTypes, ok := gr.filters()
+	if !ok {
+		g.log(ctx).Error(ERR_INVALID_GRANULARITY, zap.String("granularity", string(gr)))
+		return nil, ErrInvalidGranularity
+	}
+
+	key := "reverse|" + string(gr) + "|" + p.Key(cacheKeyPrecision)
+	if e, ok := g.cache.get(key); ok {
+		return e.point(), nil
+	}
+
+	req := &ReverseGeocodeRequest{
+		LatLng: LatLng{
+			Lat: p.Latitude,
+			Lng: p.Longitude,
+		},
+		ResultTypes:   resultTypes,
+		LocationTypes: locationTypes,
+		Language:      g.Language,
+	}
+	resp, err := g.backend().ReverseGeocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("ReverseGeocode", req, resp)
+	resp = usableResults(resp)
+
+	if len(resp) < 1 {
+		g.log(ctx).Error(NO_RESULTS, zap.String("status", STATUS_ZERO_RESULTS), zap.String("granularity", string(gr)))
+		return nil, ErrGeoCodeNoResults
+	}
+
+	r := orderByTypes(resp, resultTypes)[0]
+	pt := g.newPoint(r)
+	g.cache.put(key, pt, false, &r)
+
+	return pt, nil
+}
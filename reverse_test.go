@@ -0,0 +1,111 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestReverseGeocode(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			results := []maps.GeocodingResult{
+				fakeResult(38.2324, -122.6367, "12 Kentucky St, Petaluma, CA 94952, USA", "street_address"),
+				fakeResult(38.2324, -122.6367, "Kentucky St, Petaluma, CA 94952, USA", "route"),
+				fakeResult(38.2325, -122.6367, "Petaluma, CA, USA", "locality", "political"),
+				fakeResult(38.5780, -122.9888, "Sonoma County, CA, USA", "administrative_area_level_2", "political"),
+			}
+			if len(r.ResultType) < 1 {
+				return results, nil
+			}
+			filtered := []maps.GeocodingResult{}
+			for _, res := range results {
+				if typeRank(Result{Types: res.Types}, r.ResultType) < len(r.ResultType) {
+					filtered = append(filtered, res)
+				}
+			}
+			return filtered, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	p := &Point{Latitude: 38.2324, Longitude: -122.6367}
+	pt, err := gsc.ReverseGeocode(ctx, p, CITY)
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA, USA", pt.FormattedAddress)
+	require.Equal(t, []string{"locality"}, c.geocodeReqs[0].ResultType)
+
+	pt, err = gsc.ReverseGeocode(ctx, p, ADDRESS)
+	require.NoError(t, err)
+	require.Equal(t, "12 Kentucky St, Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, 2, len(c.geocodeReqs[1].LocationType))
+
+	_, err = gsc.ReverseGeocode(ctx, p, NEIGHBORHOOD)
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.ReverseGeocode(ctx, p, Granularity("BLOCK"))
+	require.Equal(t, ErrInvalidGranularity, err)
+}
+
+func TestGeocodeLatLongInCountry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	country := func(r maps.GeocodingResult, long, short string) maps.GeocodingResult {
+		r.AddressComponents = []maps.AddressComponent{{LongName: long, ShortName: short, Types: []string{"country", "political"}}}
+		return r
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{
+				country(fakeResult(32.5423, -117.0296, "Garita San Ysidro, Tijuana, B.C., Mexico", "premise"), "Mexico", "MX"),
+				country(fakeResult(32.5430, -117.0290, "San Ysidro, San Diego, CA 92173, USA", "neighborhood"), "United States", "US"),
+			}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{CacheSize: 10}, c)
+
+	pt, err := gsc.GeocodeLatLong(ctx, 32.5422, -117.0297, "")
+	require.NoError(t, err)
+	require.Equal(t, "Garita San Ysidro, Tijuana, B.C., Mexico", pt.FormattedAddress)
+
+	pt, err = gsc.GeocodeLatLongInCountry(ctx, 32.5422, -117.0297, "USA")
+	require.NoError(t, err)
+	require.Equal(t, "San Ysidro, San Diego, CA 92173, USA", pt.FormattedAddress)
+	require.Equal(t, 2, c.geocodeCalls())
+
+	_, err = gsc.GeocodeLatLongInCountry(ctx, 32.5422, -117.0297, "CA")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	_, err = gsc.GeocodeLatLongInCountry(ctx, 32.5422, -117.0297, " ")
+	require.Equal(t, ErrMissingCountry, err)
+}
+
+func TestGeocodeLatLongOutOfRange(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(r.LatLng.Lat, r.LatLng.Lng, "Petaluma, CA 94952, USA", "locality")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	for _, ll := range [][2]float64{{91, -122.64}, {-90.5, 0}, {38.24, 180.1}, {222, -222}} {
+		_, err := gsc.GeocodeLatLong(ctx, ll[0], ll[1], "")
+		require.Equal(t, ErrInvalidGeoLatLng, err)
+		_, err = gsc.GeocodeLatLongInCountry(ctx, ll[0], ll[1], "US")
+		require.Equal(t, ErrInvalidGeoLatLng, err)
+	}
+	require.Equal(t, 0, c.geocodeCalls())
+
+	_, err := gsc.GeocodeLatLong(ctx, 90, -180, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, c.geocodeCalls())
+}
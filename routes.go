@@ -0,0 +1,189 @@
+package geocode
+
+import (
+	"sort"
+	"time"
+
+	"googlemaps.github.io/maps"
+)
+
+// SelectRoute returns the route with the lowest cost, the first one on ties,
+// nil routes are skipped and nil is returned when there is nothing to select
+func SelectRoute(routes []*Route, cost func(*Route) float64) *Route {
+	var best *Route
+	bestCost := 0.0
+	for _, r := range routes {
+		if r == nil {
+			continue
+		}
+		c := cost(r)
+		if best == nil || c < bestCost {
+			best, bestCost = r, c
+		}
+	}
+	return best
+}
+
+// StopArrival estimated arrival at a route stop
+type StopArrival struct {
+	Stop    string
+	Offset  time.Duration
+	Arrival time.Time
+}
+
+// Itinerary returns the route's stops, starting with the first leg's start,
+// with arrival times accumulated from leg durations departing at startTime
+func (r *Route) Itinerary(startTime time.Time) []StopArrival {
+	stops := []StopArrival{}
+	offset := time.Duration(0)
+	for _, l := range r.Legs {
+		if l == nil {
+			continue
+		}
+		if len(stops) < 1 {
+			stops = append(stops, StopArrival{Stop: l.Start, Arrival: startTime})
+		}
+		offset += l.Duration
+		stops = append(stops, StopArrival{Stop: l.End, Offset: offset, Arrival: startTime.Add(offset)})
+	}
+	return stops
+}
+
+// Bounds returns the tightest box enclosing the route's polyline, for a route crossing
+// the antimeridian the longitude range wraps, with Min greater than Max
+func (r *Route) Bounds() (*RangeBounds, error) {
+	pts, err := maps.DecodePolyline(r.Polyline)
+	if err != nil || len(pts) < 1 {
+		return nil, ErrInvalidPolyline
+	}
+
+	b := &RangeBounds{
+		Latitude: Range{Min: pts[0].Lat, Max: pts[0].Lat},
+	}
+	lngs := make([]float64, 0, len(pts))
+	for _, p := range pts {
+		if p.Lat < b.Latitude.Min {
+			b.Latitude.Min = p.Lat
+		}
+		if p.Lat > b.Latitude.Max {
+			b.Latitude.Max = p.Lat
+		}
+		lngs = append(lngs, p.Lng)
+	}
+	b.Longitude = lngRange(lngs)
+	return b, nil
+}
+
+// lngRange smallest longitude range covering lngs, the complement of the widest gap between them
+func lngRange(lngs []float64) Range {
+	sort.Float64s(lngs)
+	n := len(lngs)
+
+	// gap wrapping around the antimeridian
+	gap, rng := lngs[0]+360-lngs[n-1], Range{Min: lngs[0], Max: lngs[n-1]}
+	for i := 1; i < n; i++ {
+		if d := lngs[i] - lngs[i-1]; d > gap {
+			gap, rng = d, Range{Min: lngs[i], Max: lngs[i-1]}
+		}
+	}
+	return rng
+}
+
+// FilterAlternatives keeps the routes whose duration is within maxDetourPct percent
+// of the fastest route, preserving their order
+func FilterAlternatives(routes []*Route, maxDetourPct float64) []*Route {
+	var fastest *Route
+	for _, r := range routes {
+		if r != nil && (fastest == nil || r.Duration < fastest.Duration) {
+			fastest = r
+		}
+	}
+	if fastest == nil {
+		return []*Route{}
+	}
+
+	limit := float64(fastest.Duration) * (1 + maxDetourPct/100)
+	kept := []*Route{}
+	for _, r := range routes {
+		if r != nil && float64(r.Duration) <= limit {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// OptimizeWaypointOrder returns a short visiting order of points starting at points[0], using straight line distances,
+// with roundTrip the order returns to points[0] after the last index. It's an approximation, a nearest neighbor
+// tour refined with 2-opt, meant for small problems before paying for an optimized directions request.
+func OptimizeWaypointOrder(points []*Point, roundTrip bool) ([]int, error) {
+	n := len(points)
+	dist := make([][]float64, n)
+	for i := range points {
+		dist[i] = make([]float64, n)
+		for j := range points {
+			if i == j {
+				if points[i] == nil || !points[i].IsValid() {
+					return nil, ErrInvalidGeoLatLng
+				}
+				continue
+			}
+			d, err := distance(vincentyCalculator{}, METERS, points[i], points[j])
+			if err != nil {
+				return nil, err
+			}
+			dist[i][j] = d
+		}
+	}
+
+	order := make([]int, 0, n)
+	if n == 0 {
+		return order, nil
+	}
+
+	// nearest neighbor tour
+	visited := make([]bool, n)
+	order, visited[0] = append(order, 0), true
+	for len(order) < n {
+		last, next := order[len(order)-1], -1
+		for j := 0; j < n; j++ {
+			if !visited[j] && (next < 0 || dist[last][j] < dist[last][next]) {
+				next = j
+			}
+		}
+		order, visited[next] = append(order, next), true
+	}
+
+	length := func(o []int) float64 {
+		total := 0.0
+		for i := 1; i < len(o); i++ {
+			total += dist[o[i-1]][o[i]]
+		}
+		if roundTrip {
+			total += dist[o[len(o)-1]][o[0]]
+		}
+		return total
+	}
+
+	// 2-opt, reversing segments while that shortens the tour, the start stays fixed
+	best := length(order)
+	for improved := true; improved; {
+		improved = false
+		for i := 1; i < n-1; i++ {
+			for k := i + 1; k < n; k++ {
+				reverse(order[i : k+1])
+				if l := length(order); l < best-1e-9 {
+					best, improved = l, true
+				} else {
+					reverse(order[i : k+1])
+				}
+			}
+		}
+	}
+	return order, nil
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,140 @@
+package geocode
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestRoutes(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"select route by cost, succeeds":       testSelectRoute,
+		"itinerary arrival times, succeeds":    testItinerary,
+		"route polyline bounds, succeeds":      testRouteBounds,
+		"filter detour alternatives, succeeds": testFilterAlternatives,
+		"optimize waypoint order, succeeds":    testOptimizeWaypointOrder,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testSelectRoute(t *testing.T) {
+	fastest := &Route{Summary: "I-280", Duration: 30 * time.Minute, Distance: 60000}
+	shortest := &Route{Summary: "El Camino", Duration: 50 * time.Minute, Distance: 45000}
+	balanced := &Route{Summary: "US-101", Duration: 32 * time.Minute, Distance: 50000}
+	routes := []*Route{fastest, nil, shortest, balanced}
+
+	weighted := func(r *Route) float64 {
+		return 0.7*r.Duration.Minutes() + 0.3*float64(r.Distance)/1000
+	}
+	require.Equal(t, balanced, SelectRoute(routes, weighted))
+
+	byDistance := func(r *Route) float64 { return float64(r.Distance) }
+	require.Equal(t, shortest, SelectRoute(routes, byDistance))
+
+	byDuration := func(r *Route) float64 { return r.Duration.Seconds() }
+	require.Equal(t, fastest, SelectRoute(routes, byDuration))
+
+	require.Nil(t, SelectRoute(nil, byDuration))
+}
+
+func testItinerary(t *testing.T) {
+	r := &Route{Legs: []*RouteLeg{
+		{Start: "Depot", End: "Stop A", Duration: 20 * time.Minute},
+		{Start: "Stop A", End: "Stop B", Duration: 35 * time.Minute},
+		{Start: "Stop B", End: "Depot", Duration: 45 * time.Minute},
+	}}
+	start := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+
+	stops := r.Itinerary(start)
+	require.Equal(t, []StopArrival{
+		{Stop: "Depot", Offset: 0, Arrival: start},
+		{Stop: "Stop A", Offset: 20 * time.Minute, Arrival: start.Add(20 * time.Minute)},
+		{Stop: "Stop B", Offset: 55 * time.Minute, Arrival: start.Add(55 * time.Minute)},
+		{Stop: "Depot", Offset: 100 * time.Minute, Arrival: time.Date(2024, 3, 1, 9, 40, 0, 0, time.UTC)},
+	}, stops)
+
+	require.Empty(t, (&Route{}).Itinerary(start))
+}
+
+func testRouteBounds(t *testing.T) {
+	path := []maps.LatLng{
+		{Lat: 37.42, Lng: -122.08},
+		{Lat: 37.39, Lng: -122.03},
+		{Lat: 37.45, Lng: -121.95},
+		{Lat: 37.33, Lng: -121.89},
+	}
+	r := &Route{Polyline: maps.Encode(path)}
+	b, err := r.Bounds()
+	require.NoError(t, err)
+	require.InDelta(t, 37.33, b.Latitude.Min, 1e-5)
+	require.InDelta(t, 37.45, b.Latitude.Max, 1e-5)
+	require.InDelta(t, -122.08, b.Longitude.Min, 1e-5)
+	require.InDelta(t, -121.89, b.Longitude.Max, 1e-5)
+	for _, p := range path {
+		require.True(t, p.Lat >= b.Latitude.Min-1e-5 && p.Lat <= b.Latitude.Max+1e-5)
+		require.True(t, p.Lng >= b.Longitude.Min-1e-5 && p.Lng <= b.Longitude.Max+1e-5)
+	}
+
+	// ferry across the antimeridian
+	r = &Route{Polyline: maps.Encode([]maps.LatLng{
+		{Lat: -17.7, Lng: 178.4},
+		{Lat: -16.5, Lng: 179.9},
+		{Lat: -16.0, Lng: -179.8},
+	})}
+	b, err = r.Bounds()
+	require.NoError(t, err)
+	require.InDelta(t, 178.4, b.Longitude.Min, 1e-5)
+	require.InDelta(t, -179.8, b.Longitude.Max, 1e-5)
+
+	_, err = (&Route{}).Bounds()
+	require.Equal(t, ErrInvalidPolyline, err)
+}
+
+func testFilterAlternatives(t *testing.T) {
+	fastest := &Route{Summary: "I-280", Duration: 40 * time.Minute}
+	near := &Route{Summary: "US-101", Duration: 46 * time.Minute}
+	edge := &Route{Summary: "CA-82", Duration: 50 * time.Minute}
+	slow := &Route{Summary: "CA-1", Duration: 75 * time.Minute}
+
+	kept := FilterAlternatives([]*Route{near, slow, fastest, nil, edge}, 25)
+	require.Equal(t, []*Route{near, fastest, edge}, kept)
+
+	kept = FilterAlternatives([]*Route{near, slow, fastest}, 0)
+	require.Equal(t, []*Route{fastest}, kept)
+
+	require.Empty(t, FilterAlternatives(nil, 10))
+}
+
+func testOptimizeWaypointOrder(t *testing.T) {
+	// stops along a road, out of order
+	line := []*Point{
+		{Latitude: 10, Longitude: 10.00},
+		{Latitude: 10, Longitude: 10.03},
+		{Latitude: 10, Longitude: 10.01},
+		{Latitude: 10, Longitude: 10.02},
+	}
+	order, err := OptimizeWaypointOrder(line, false)
+	require.NoError(t, err)
+	require.Equal(t, []int{0, 2, 3, 1}, order)
+
+	// the corners of a square, the round trip goes around it
+	square := []*Point{
+		{Latitude: 10.00, Longitude: 10.00},
+		{Latitude: 10.01, Longitude: 10.01},
+		{Latitude: 10.01, Longitude: 10.00},
+		{Latitude: 10.00, Longitude: 10.01},
+	}
+	order, err = OptimizeWaypointOrder(square, true)
+	require.NoError(t, err)
+	require.Contains(t, [][]int{{0, 2, 1, 3}, {0, 3, 1, 2}}, order)
+
+	order, err = OptimizeWaypointOrder(nil, true)
+	require.NoError(t, err)
+	require.Empty(t, order)
+
+	_, err = OptimizeWaypointOrder([]*Point{line[0], nil}, false)
+	require.Equal(t, ErrInvalidGeoLatLng, err)
+}
@@ -0,0 +1,15 @@
+package geocode
+
+import (
+	"context"
+)
+
+// GeocodeSimple is a convenience wrapper around GeocodeAddress for scripts,
+// it runs with a background context bounded by DEFAULT_SIMPLE_TIMEOUT.
+// Services should use the context based methods for cancellation and tracing.
+func (g *geoCodeService) GeocodeSimple(addr *AddressQuery) (*Point, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DEFAULT_SIMPLE_TIMEOUT)
+	defer cancel()
+
+	return g.GeocodeAddress(ctx, addr)
+}
@@ -0,0 +1,27 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodeSimple(t *testing.T) {
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(47.61, -122.33, "Seattle, WA, USA", "locality")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	addr := &AddressQuery{City: "Seattle", State: "WA"}
+	want, err := gsc.GeocodeAddress(context.Background(), addr)
+	require.NoError(t, err)
+
+	got, err := gsc.GeocodeSimple(addr)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+	require.Equal(t, c.geocodeReqs[0], c.geocodeReqs[1])
+}
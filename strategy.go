@@ -0,0 +1,104 @@
+package geocode
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// Strategy how an address was geocoded
+type Strategy string
+
+const (
+	STRATEGY_COMPONENTS  Strategy = "COMPONENTS"
+	STRATEGY_FREE_TEXT   Strategy = "FREE_TEXT"
+	STRATEGY_POSTAL_CODE Strategy = "POSTAL_CODE"
+)
+
+// GeocodeBestEffort tries component filtered geocoding, then the free text address,
+// then the postal code centroid, returning the first success and the strategy that produced it
+func (g *geoCodeService) GeocodeBestEffort(ctx context.Context, addr *AddressQuery) (*Point, Strategy, error) {
+	if ctx == nil {
+		g.log(ctx).Error("context is nil", zap.Error(ErrNilContext))
+		return nil, "", ErrNilContext
+	}
+	if addr == nil {
+		return nil, "", ErrInvalidAddress
+	}
+
+	q := *addr
+	if q.Country == "" {
+		q.Country = "USA"
+	}
+
+	pt, err := g.geocodeComponents(ctx, &q)
+	if err == nil {
+		return pt, STRATEGY_COMPONENTS, nil
+	}
+	if err == ErrOffline || ctx.Err() != nil {
+		return nil, "", err
+	}
+	g.log(ctx).Info("component geocoding failed, trying free text", zap.Error(err))
+
+	pt, meta, _, err := g.geocodeAddress(ctx, &q)
+	if err == nil {
+		if meta.Degraded {
+			return pt, STRATEGY_POSTAL_CODE, nil
+		}
+		return pt, STRATEGY_FREE_TEXT, nil
+	}
+	if err == ErrOffline || ctx.Err() != nil || q.PostalCode == "" {
+		return nil, "", err
+	}
+	g.log(ctx).Info("free text geocoding failed, trying postal code", zap.Error(err))
+
+	pt, err = g.Geocode(ctx, q.PostalCode, q.Country)
+	if err != nil {
+		return nil, "", err
+	}
+	return pt, STRATEGY_POSTAL_CODE, nil
+}
+
+// geocodeComponents geocodes addr using component filters, the route filter takes the street name only
+// so the house number and unit aren't sent, premise and subpremise go in the free text address
+func (g *geoCodeService) geocodeComponents(ctx context.Context, addr *AddressQuery) (*Point, error) {
+	comps := map[string]string{}
+	for c, v := range map[string]string{
+		"route":               streetName(addr.Street),
+		"locality":            addr.City,
+		"administrative_area": addr.State,
+		"postal_code":         addr.PostalCode,
+		"country":             addr.Country,
+	} {
+		if v != "" {
+			comps[c] = v
+		}
+	}
+	if addr.Street == "" && addr.City == "" && addr.PostalCode == "" {
+		return nil, ErrInvalidAddress
+	}
+
+	req := &GeocodeRequest{
+		Address:    addr.field(FIELD_PREMISE),
+		Components: comps,
+		Language:   g.Language,
+		Region:     g.Region,
+	}
+	resp, err := g.backend().Geocode(ctx, req)
+	if err != nil {
+		g.log(ctx).Error(ERROR_GEOCODING_ADDRESS, zap.Error(err), statusField(err))
+		return nil, apiError(err, ErrGeoCodeAddress)
+	}
+	g.audit("GeocodeBestEffort", req, resp)
+	resp = usableResults(resp)
+
+	if len(resp) < 1 {
+		return nil, ErrGeoCodeNoResults
+	}
+
+	r, err := g.selectResult(ctx, resp, false)
+	if err != nil {
+		return nil, err
+	}
+	return g.newPoint(r), nil
+}
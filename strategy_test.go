@@ -0,0 +1,88 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestGeocodeBestEffort(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			switch {
+			case r.Address != "":
+				return nil, nil
+			case r.Components[maps.ComponentRoute] != "":
+				return nil, errors.New("maps: INVALID_REQUEST - ")
+			default:
+				return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Petaluma, CA 94952, USA", "postal_code")}, nil
+			}
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	pt, strategy, err := gsc.GeocodeBestEffort(ctx, &AddressQuery{Street: "12 Nowhere Ln", City: "Petaluma", PostalCode: "94952"})
+	require.NoError(t, err)
+	require.Equal(t, STRATEGY_POSTAL_CODE, strategy)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, 3, c.geocodeCalls())
+
+	_, strategy, err = gsc.GeocodeBestEffort(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, STRATEGY_COMPONENTS, strategy)
+
+	_, _, err = gsc.GeocodeBestEffort(ctx, &AddressQuery{Street: "12 Nowhere Ln", City: "Petaluma"})
+	require.Equal(t, ErrGeoCodeNoResults, err)
+
+	calls := c.geocodeCalls()
+	_, _, err = gsc.GeocodeBestEffort(ctx, nil)
+	require.Equal(t, ErrInvalidAddress, err)
+	require.Equal(t, calls, c.geocodeCalls())
+}
+
+func TestGeocodeComponentsRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "Main St, Petaluma, CA 94952, USA", "route")}, nil
+		},
+	}
+	gsc := newFakeService(t, Config{}, c)
+
+	_, strategy, err := gsc.GeocodeBestEffort(ctx, &AddressQuery{
+		Street:     "Unit 4, 123 Main St",
+		City:       "Petaluma",
+		State:      "CA",
+		PostalCode: "94952",
+		Premise:    "Building 40",
+		Subpremise: "Suite 210",
+	})
+	require.NoError(t, err)
+	require.Equal(t, STRATEGY_COMPONENTS, strategy)
+	require.Equal(t, "Suite 210 Building 40", c.geocodeReqs[0].Address)
+	require.Equal(t, map[maps.Component]string{
+		maps.ComponentRoute:              "Main St",
+		maps.ComponentLocality:           "Petaluma",
+		maps.ComponentAdministrativeArea: "CA",
+		maps.ComponentPostalCode:         "94952",
+		maps.ComponentCountry:            "USA",
+	}, c.geocodeReqs[0].Components)
+
+	for street, name := range map[string]string{
+		"123 Main St":         "Main St",
+		"12B Main St #4":      "Main St",
+		"1600-1610 Market St": "Market St",
+		"1st Ave":             "1st Ave",
+		"55 2nd St Apt 3":     "2nd St",
+	} {
+		require.Equal(t, name, streetName(street), street)
+	}
+}
@@ -0,0 +1,38 @@
+package geocode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SuggestionsError is returned for weak geocoding matches, Suggestions holds the
+// formatted addresses of the near-miss results so callers can prompt the user
+type SuggestionsError struct {
+	Err         error
+	Suggestions []string
+}
+
+func (e *SuggestionsError) Error() string {
+	if len(e.Suggestions) < 1 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s, did you mean: %s", e.Err.Error(), strings.Join(e.Suggestions, "; "))
+}
+
+func (e *SuggestionsError) Unwrap() error {
+	return e.Err
+}
+
+// newSuggestionsError collects the distinct formatted addresses of results
+func newSuggestionsError(err error, results []Result) *SuggestionsError {
+	seen := map[string]bool{}
+	suggestions := []string{}
+	for _, r := range results {
+		if r.FormattedAddress == "" || seen[r.FormattedAddress] {
+			continue
+		}
+		seen[r.FormattedAddress] = true
+		suggestions = append(suggestions, r.FormattedAddress)
+	}
+	return &SuggestionsError{Err: err, Suggestions: suggestions}
+}
@@ -0,0 +1,46 @@
+package geocode
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestSuggestions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	near := []maps.GeocodingResult{
+		fakeResult(40.75, -73.99, "123 Main St, Brooklyn, NY 11201, USA", "street_address"),
+		fakeResult(40.71, -73.80, "123 Main St, Queens, NY 11354, USA", "street_address"),
+		fakeResult(40.75, -73.99, "123 Main St, Brooklyn, NY 11201, USA", "street_address"),
+	}
+	for i := range near {
+		near[i].PartialMatch = true
+	}
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return near, nil
+		},
+	}
+	addr := &AddressQuery{Street: "123 Mian St", City: "New York", State: "NY"}
+
+	gsc := newFakeService(t, Config{}, c)
+	pt, err := gsc.GeocodeAddress(ctx, addr)
+	require.NoError(t, err)
+	require.Equal(t, near[0].FormattedAddress, pt.FormattedAddress)
+
+	gsc = newFakeService(t, Config{RejectPartialMatches: true}, c)
+	_, err = gsc.GeocodeAddress(ctx, addr)
+	var serr *SuggestionsError
+	require.True(t, errors.As(err, &serr))
+	require.True(t, errors.Is(err, ErrGeoCodeNoResults))
+	require.Equal(t, []string{
+		"123 Main St, Brooklyn, NY 11201, USA",
+		"123 Main St, Queens, NY 11354, USA",
+	}, serr.Suggestions)
+	require.Contains(t, err.Error(), "did you mean")
+}
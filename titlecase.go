@@ -0,0 +1,93 @@
+package geocode
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// addressAcronyms words kept upper case when title casing addresses, country codes and directionals
+var addressAcronyms = map[string]bool{}
+
+// stateCodes US state and Canadian province abbreviations, kept upper case in the state position only
+// as many are also words, e.g. "La Jolla", "De Pere" or "Stratford On Avon"
+var stateCodes = map[string]bool{}
+
+func init() {
+	for _, w := range strings.Fields(`
+		USA US UK UAE PO
+		N S E W NE NW SE SW NNE ENE ESE SSE SSW WSW WNW NNW`) {
+		addressAcronyms[w] = true
+	}
+	for _, w := range strings.Fields(`
+		AL AK AZ AR CA CO CT DE DC FL GA HI ID IL IN IA KS KY LA ME MD MA MI MN MS MO
+		MT NE NV NH NJ NM NY NC ND OH OK OR PA RI SC SD TN TX UT VT VA WA WV WI WY PR
+		AB BC MB NB NL NS NT NU ON PE QC SK YT`) {
+		stateCodes[w] = true
+	}
+}
+
+// addressMinorWords lower cased within title cased addresses
+var addressMinorWords = map[string]bool{"of": true, "and": true}
+
+var ordinal = regexp.MustCompile(`^(?i)(\d+)(st|nd|rd|th)$`)
+
+// titleCase title cases the all upper or all lower case words of an address, keeping acronyms upper case,
+// mixed case words, e.g. "McDonald", and words with digits other than ordinals, e.g. UK postcodes, are kept as is
+func titleCase(s string) string {
+	words := strings.Split(s, " ")
+	for i, w := range words {
+		// keep punctuation, e.g. the trailing comma, around the word
+		start := strings.IndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+		end := strings.LastIndexFunc(w, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+		if start < 0 {
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(w[end:])
+		end += size
+		words[i] = w[:start] + titleWord(w[start:end], i == 0, statePosition(words, i, w[end:])) + w[end:]
+	}
+	return strings.Join(words, " ")
+}
+
+// statePosition reports whether the i-th word, followed by trailing punctuation, is where a state goes,
+// right before the postal code, e.g. "CA 94043", or alone between commas, e.g. "Springfield, IL, USA"
+func statePosition(words []string, i int, trailing string) bool {
+	if trailing == "" && i+1 < len(words) && strings.IndexFunc(words[i+1], unicode.IsDigit) >= 0 {
+		return true
+	}
+	segmentStart := i == 0 || strings.HasSuffix(words[i-1], ",")
+	segmentEnd := i == len(words)-1 || strings.HasPrefix(trailing, ",")
+	return i > 0 && segmentStart && segmentEnd
+}
+
+func titleWord(w string, first, state bool) string {
+	if m := ordinal.FindStringSubmatch(w); m != nil {
+		return m[1] + strings.ToLower(m[2])
+	}
+	if strings.IndexFunc(w, unicode.IsDigit) >= 0 {
+		return w
+	}
+	upper, lower := strings.ToUpper(w), strings.ToLower(w)
+	if w != upper && w != lower {
+		return w
+	}
+	if addressAcronyms[upper] || (state && stateCodes[upper]) {
+		return upper
+	}
+	if !first && addressMinorWords[lower] {
+		return lower
+	}
+
+	// capitalize each hyphenated part, e.g. Winston-Salem
+	parts := strings.Split(lower, "-")
+	for i, p := range parts {
+		rs := []rune(p)
+		if len(rs) > 0 {
+			rs[0] = unicode.ToUpper(rs[0])
+		}
+		parts[i] = string(rs)
+	}
+	return strings.Join(parts, "-")
+}
@@ -0,0 +1,46 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+func TestTitleCase(t *testing.T) {
+	for in, want := range map[string]string{
+		"1600 AMPHITHEATRE PKWY, MOUNTAIN VIEW, CA 94043, USA": "1600 Amphitheatre Pkwy, Mountain View, CA 94043, USA",
+		"123 main st nw, washington, dc 20001, usa":            "123 Main St NW, Washington, DC 20001, USA",
+		"350 5TH AVE, NEW YORK, NY 10118, USA":                 "350 5th Ave, New York, NY 10118, USA",
+		"10 Downing St, LONDON SW1A 2AA, UK":                   "10 Downing St, London SW1A 2AA, UK",
+		"1 MCDONALD'S PLAZA, WINSTON-SALEM, NC 27101":          "1 Mcdonald's Plaza, Winston-Salem, NC 27101",
+		"500 McDonald Ave, Brooklyn, NY 11218, USA":            "500 McDonald Ave, Brooklyn, NY 11218, USA",
+		"DISTRICT OF COLUMBIA, USA":                            "District of Columbia, USA",
+		"MARIENPLATZ 1, 80331 MÜNCHEN, GERMANY":                "Marienplatz 1, 80331 München, Germany",
+		"LA JOLLA, CA 92037, USA":                              "La Jolla, CA 92037, USA",
+		"100 MAIN AVE, DE PERE, WI 54115, USA":                 "100 Main Ave, De Pere, WI 54115, USA",
+		"STRATFORD ON AVON, ON N5A 6S4, CANADA":                "Stratford On Avon, ON N5A 6S4, Canada",
+		"1 HOUSE IN THE WOODS, SPRINGFIELD, IL, USA":           "1 House In The Woods, Springfield, IL, USA",
+		"OR ELSE CAFE, PORTLAND, OR 97201":                     "Or Else Cafe, Portland, OR 97201",
+		"":                                                     "",
+	} {
+		require.Equal(t, want, titleCase(in), in)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{
+		geocodeFn: func(r *maps.GeocodingRequest) ([]maps.GeocodingResult, error) {
+			return []maps.GeocodingResult{fakeResult(38.24, -122.64, "PETALUMA, CA 94952, USA", "postal_code")}, nil
+		},
+	}
+	pt, err := newFakeService(t, Config{}, c).Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+	require.Equal(t, "PETALUMA, CA 94952, USA", pt.FormattedAddress)
+
+	pt, err = newFakeService(t, Config{TitleCaseAddresses: true}, c).Geocode(ctx, "94952", "")
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+}
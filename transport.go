@@ -0,0 +1,117 @@
+package geocode
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// userAgentTransport sets a descriptive User-Agent on outgoing requests
+type userAgentTransport struct {
+	userAgent string
+	base      http.RoundTripper
+}
+
+func newUserAgentTransport(userAgent string, base http.RoundTripper) *userAgentTransport {
+	if userAgent == "" {
+		userAgent = DEFAULT_USER_AGENT
+	}
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &userAgentTransport{
+		userAgent: userAgent,
+		base:      base,
+	}
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.Clone(req.Context())
+	ua := t.userAgent
+	if existing := r.Header.Get("User-Agent"); existing != "" {
+		ua = ua + " " + existing
+	}
+	r.Header.Set("User-Agent", ua)
+	return t.base.RoundTrip(r)
+}
+
+// serverError 5xx response of the api, which the maps client would otherwise fail to decode
+type serverError struct {
+	statusCode int
+}
+
+func (e *serverError) Error() string {
+	return fmt.Sprintf("google maps api responded %d %s", e.statusCode, http.StatusText(e.statusCode))
+}
+
+// serverErrorTransport fails requests answered with a 5xx status with a serverError
+type serverErrorTransport struct {
+	base http.RoundTripper
+}
+
+func (t *serverErrorTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= http.StatusInternalServerError {
+		resp.Body.Close()
+		return nil, &serverError{statusCode: resp.StatusCode}
+	}
+	return resp, nil
+}
+
+// rawCapture holds the most recent raw api response body
+type rawCapture struct {
+	mu   sync.Mutex
+	last []byte
+}
+
+func (c *rawCapture) set(b []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.last = b
+}
+
+// get returns a copy of the most recent body, nil for a nil capture
+func (c *rawCapture) get() []byte {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.last...)
+}
+
+// rawCaptureTransport records response bodies, handing the caller an unread copy
+type rawCaptureTransport struct {
+	capture *rawCapture
+	base    http.RoundTripper
+}
+
+func newRawCaptureTransport(capture *rawCapture, base http.RoundTripper) *rawCaptureTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &rawCaptureTransport{
+		capture: capture,
+		base:    base,
+	}
+}
+
+func (t *rawCaptureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	t.capture.set(b)
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	return resp, nil
+}
@@ -0,0 +1,130 @@
+package geocode
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/comfforts/logger"
+	"github.com/stretchr/testify/require"
+	"googlemaps.github.io/maps"
+)
+
+type recordingTransport struct {
+	reqs []*http.Request
+}
+
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.reqs = append(t.reqs, req)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"status":"OK"}`)),
+		Request:    req,
+	}, nil
+}
+
+func TestUserAgentTransport(t *testing.T) {
+	rec := &recordingTransport{}
+	client := &http.Client{Transport: newUserAgentTransport("acme-dispatch/1.2", rec)}
+
+	resp, err := client.Get("https://maps.googleapis.com/maps/api/geocode/json")
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, "acme-dispatch/1.2", rec.reqs[0].Header.Get("User-Agent"))
+
+	req, err := http.NewRequest(http.MethodGet, "https://maps.googleapis.com/maps/api/geocode/json", nil)
+	require.NoError(t, err)
+	req.Header.Set("User-Agent", "GoogleGeoApiClientGo/v1.4.0")
+	client = &http.Client{Transport: newUserAgentTransport("", rec)}
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, DEFAULT_USER_AGENT+" GoogleGeoApiClientGo/v1.4.0", rec.reqs[1].Header.Get("User-Agent"))
+	require.Equal(t, "GoogleGeoApiClientGo/v1.4.0", req.Header.Get("User-Agent"))
+}
+
+func TestServerErrorTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = io.WriteString(w, "<html>unavailable</html>")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"status":"OK","results":[{"formatted_address":"Petaluma, CA 94952, USA","geometry":{"location":{"lat":38.24,"lng":-122.64}}}]}`)
+	}))
+	defer srv.Close()
+
+	c, err := maps.NewClient(
+		maps.WithAPIKey("test-key"),
+		maps.WithBaseURL(srv.URL),
+		maps.WithHTTPClient(&http.Client{Transport: &serverErrorTransport{base: http.DefaultTransport}}),
+	)
+	require.NoError(t, err)
+
+	_, err = c.Geocode(ctx, &maps.GeocodingRequest{Address: "Petaluma, CA"})
+	require.Error(t, err)
+	require.True(t, retryable(err))
+
+	// the 5xx response is retried
+	calls = 0
+	gsc, err := newGeoCodeService(Config{
+		MaxRetries: 1,
+		Clock:      newFakeClock(),
+		AppLogger:  logger.NewTestAppLogger(t.TempDir()),
+	}, c)
+	require.NoError(t, err)
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, 2, calls)
+}
+
+func TestRawCaptureTransport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	body := `{"status":"OK","results":[{"formatted_address":"Petaluma, CA 94952, USA","geometry":{"location":{"lat":38.24,"lng":-122.64}},"types":["locality"]}]}`
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, body)
+	}))
+	defer srv.Close()
+
+	raw := &rawCapture{}
+	c, err := maps.NewClient(
+		maps.WithAPIKey("test-key"),
+		maps.WithBaseURL(srv.URL),
+		maps.WithHTTPClient(&http.Client{Transport: newRawCaptureTransport(raw, nil)}),
+	)
+	require.NoError(t, err)
+	gsc, err := newGeoCodeService(Config{AppLogger: logger.NewTestAppLogger(t.TempDir())}, c)
+	require.NoError(t, err)
+	require.Nil(t, gsc.LastRawResponse())
+
+	gsc.raw = raw
+	pt, err := gsc.GeocodeAddress(ctx, &AddressQuery{City: "Petaluma", State: "CA"})
+	require.NoError(t, err)
+	require.Equal(t, "Petaluma, CA 94952, USA", pt.FormattedAddress)
+	require.Equal(t, body, string(gsc.LastRawResponse()))
+
+	gsc.LastRawResponse()[0] = 'x'
+	require.Equal(t, body, string(gsc.LastRawResponse()))
+	require.True(t, gsc.EffectiveConfig().CaptureRawResponses)
+
+	gsc, err = NewGeoCodeService(Config{
+		GeocoderKey:         "test-key",
+		CaptureRawResponses: true,
+		AppLogger:           logger.NewTestAppLogger(t.TempDir()),
+	})
+	require.NoError(t, err)
+	require.NotNil(t, gsc.raw)
+}
@@ -0,0 +1,30 @@
+package geocode
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const mapsURLBase = "https://www.google.com/maps"
+
+// MapsURL returns a Google Maps link to the point's coordinates
+func (p *Point) MapsURL() string {
+	q := url.Values{}
+	q.Set("api", "1")
+	q.Set("query", fmt.Sprintf("%.6f,%.6f", p.Latitude, p.Longitude))
+	return fmt.Sprintf("%s/search/?%s", mapsURLBase, q.Encode())
+}
+
+// MapsDirectionsURL returns a Google Maps directions link from the route's
+// first leg start to its last leg end, empty for a route without legs
+func (r *Route) MapsDirectionsURL() string {
+	if len(r.Legs) < 1 || r.Legs[0] == nil || r.Legs[len(r.Legs)-1] == nil {
+		return ""
+	}
+
+	q := url.Values{}
+	q.Set("api", "1")
+	q.Set("origin", r.Legs[0].Start)
+	q.Set("destination", r.Legs[len(r.Legs)-1].End)
+	return fmt.Sprintf("%s/dir/?%s", mapsURLBase, q.Encode())
+}
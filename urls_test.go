@@ -0,0 +1,23 @@
+package geocode
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMapsURL(t *testing.T) {
+	p := &Point{Latitude: 37.422, Longitude: -122.084058}
+	require.Equal(t, "https://www.google.com/maps/search/?api=1&query=37.422000%2C-122.084058", p.MapsURL())
+
+	r := &Route{Legs: []*RouteLeg{
+		{Start: "1600 Amphitheatre Pkwy, Mountain View, CA", End: "San Jose, CA"},
+		{Start: "San Jose, CA", End: "Santa Cruz, CA"},
+	}}
+	u := r.MapsDirectionsURL()
+	require.Contains(t, u, "https://www.google.com/maps/dir/?api=1")
+	require.Contains(t, u, "origin=1600+Amphitheatre+Pkwy%2C+Mountain+View%2C+CA")
+	require.Contains(t, u, "destination=Santa+Cruz%2C+CA")
+
+	require.Equal(t, "", (&Route{}).MapsDirectionsURL())
+}
@@ -0,0 +1,76 @@
+package geocode
+
+import (
+	"regexp"
+	"strings"
+)
+
+// postalCodeFormats by ISO 3166-1 alpha-2 country code
+var postalCodeFormats = map[string]*regexp.Regexp{
+	"US": regexp.MustCompile(`^\d{5}(-?\d{4})?$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]?(\s?\d[A-Z]{2})?$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z]\s?\d[A-Z]\d$`),
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"NL": regexp.MustCompile(`^\d{4}\s?[A-Z]{2}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+}
+
+var countryAliases = map[string]string{
+	"USA": "US",
+	"UK":  "GB",
+	"GBR": "GB",
+	"CAN": "CA",
+	"AUS": "AU",
+	"DEU": "DE",
+	"FRA": "FR",
+	"IND": "IN",
+	"NLD": "NL",
+	"JPN": "JP",
+}
+
+// ValidatePostalCode checks code against the postal code format of country,
+// codes for countries without a known format are only checked for being non empty
+func ValidatePostalCode(code, country string) error {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if code == "" {
+		return ErrInvalidPostalCode
+	}
+
+	format, ok := postalCodeFormats[countryCode(country)]
+	if ok && !format.MatchString(code) {
+		return ErrInvalidPostalCode
+	}
+	return nil
+}
+
+// ValidateAddresses checks addrs without calling the api, returning errors parallel to addrs,
+// nil for rows that look geocodable
+func ValidateAddresses(addrs []*AddressQuery) []error {
+	errs := make([]error, len(addrs))
+	for i, a := range addrs {
+		switch {
+		case a == nil || !a.IsValid():
+			errs[i] = ErrInvalidAddress
+		case strings.TrimSpace(a.Country) == "":
+			errs[i] = ErrMissingCountry
+		case a.PostalCode != "":
+			errs[i] = ValidatePostalCode(a.PostalCode, a.Country)
+		}
+	}
+	return errs
+}
+
+// countryCode normalizes a country name or code to its alpha-2 code, defaulting to US
+func countryCode(country string) string {
+	c := strings.ToUpper(strings.TrimSpace(country))
+	if c == "" {
+		return "US"
+	}
+	if alias, ok := countryAliases[c]; ok {
+		return alias
+	}
+	return c
+}
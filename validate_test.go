@@ -0,0 +1,80 @@
+package geocode
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	for scenario, fn := range map[string]func(t *testing.T){
+		"postal code formats, succeeds":      testValidatePostalCode,
+		"strict postal validation, succeeds": testStrictPostalValidation,
+		"validate address rows, succeeds":    testValidateAddresses,
+	} {
+		t.Run(scenario, fn)
+	}
+}
+
+func testValidatePostalCode(t *testing.T) {
+	for _, v := range []struct {
+		code, country string
+		valid         bool
+	}{
+		{"94952", "US", true},
+		{"94952-1234", "USA", true},
+		{"949521234", "", true},
+		{"9495", "US", false},
+		{"94A52", "US", false},
+		{"SW1A 1AA", "UK", true},
+		{"ec1a1bb", "GB", true},
+		{"EC1A", "GB", true},
+		{"SW1A 1A", "UK", false},
+		{"12345", "UK", false},
+		{"", "US", false},
+		{"any-format", "BR", true},
+	} {
+		err := ValidatePostalCode(v.code, v.country)
+		if v.valid {
+			require.NoError(t, err, "%s %s", v.code, v.country)
+		} else {
+			require.Equal(t, ErrInvalidPostalCode, err, "%s %s", v.code, v.country)
+		}
+	}
+}
+
+func testStrictPostalValidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := &fakeMapsClient{}
+	gsc := newFakeService(t, Config{StrictPostalValidation: true}, c)
+
+	_, err := gsc.Geocode(ctx, "9495", "")
+	require.Equal(t, ErrInvalidPostalCode, err)
+	require.Equal(t, 0, c.geocodeCalls())
+
+	_, err = gsc.Geocode(ctx, "94952", "")
+	require.Equal(t, ErrGeoCodeNoResults, err)
+	require.Equal(t, 1, c.geocodeCalls())
+}
+
+func testValidateAddresses(t *testing.T) {
+	errs := ValidateAddresses([]*AddressQuery{
+		{Street: "1600 Amphitheatre Pkwy", City: "Mountain View", State: "CA", PostalCode: "94043", Country: "US"},
+		nil,
+		{Country: "US"},
+		{City: "Petaluma", State: "CA"},
+		{City: "Toronto", PostalCode: "12345", Country: "CAN"},
+		{City: "London", Country: "GB"},
+	})
+	require.Equal(t, []error{
+		nil,
+		ErrInvalidAddress,
+		ErrInvalidAddress,
+		ErrMissingCountry,
+		ErrInvalidPostalCode,
+		nil,
+	}, errs)
+}